@@ -0,0 +1,92 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 22:20:31
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 22:20:31
+ * @FilePath: \gosh\access_log.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"go.uber.org/zap"
+)
+
+// AccessLogConfig 访问日志中间件配置
+type AccessLogConfig struct {
+	Logger    *Logger  // 记录日志的 Logger(默认Config.Zap)，为空且引擎没有配置日志时输出到标准库日志
+	SkipPaths []string // 不记录的请求路径，例如健康检查
+}
+
+// AccessLog 返回访问日志中间件，在请求结束后记录一行日志
+// 包含方法、路径、路由、状态码、耗时、响应大小与客户端 IP，存在私有错误时一并记录；
+// 状态码 5xx 使用 Error 级别，4xx 使用 Warn 级别，其余使用 Info 级别
+func AccessLog(config ...AccessLogConfig) HandlerFunc {
+	var cfg AccessLogConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	skipPaths := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skipPaths[path] = struct{}{}
+	}
+
+	return func(ctx *Context) error {
+		start := time.Now()
+		path := ctx.Request.URL.Path
+		ctx.Next()
+		if _, skip := skipPaths[path]; skip {
+			return nil
+		}
+
+		writer := ctx.Writer()
+		status := writer.Status()
+		latency := time.Since(start)
+		privateErrors := ctx.Errors().ByType(errorsx.ErrorTypePrivate)
+
+		logger := cfg.Logger
+		if logger == nil && ctx.Engine != nil {
+			logger = ctx.Engine.Config.Zap
+		}
+		if logger == nil {
+			line := fmt.Sprintf("%s %s %d %v %s", ctx.Method(), path, status, latency, ctx.ClientIP())
+			if len(privateErrors) > 0 {
+				line += fmt.Sprintf(" errors=%q", privateErrors.Errors())
+			}
+			ctx.logPrintln(line)
+			return nil
+		}
+
+		fields := []zap.Field{
+			zap.String("method", ctx.Method()),
+			zap.String("path", path),
+			zap.String("route", ctx.FullPath()),
+			zap.Int("status", status),
+			zap.Duration("latency", latency),
+			zap.Int("size", writer.Size()),
+			zap.String("client_ip", ctx.ClientIP()),
+			zap.String("user_agent", ctx.UserAgent()),
+		}
+		if len(privateErrors) > 0 {
+			fields = append(fields, zap.Strings("errors", privateErrors.Errors()))
+		}
+
+		logger = logger.WithRequestID(ctx.RequestID())
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Error("access", fields...)
+		case status >= http.StatusBadRequest:
+			logger.Warn("access", fields...)
+		default:
+			logger.Info("access", fields...)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 22:38:47
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 22:38:47
+ * @FilePath: \gosh\access_log_test.go
+ * @Description: 测试访问日志功能
+ */
+package gosh
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestAccessLog 测试访问日志的字段、级别、私有错误与跳过路径
+func TestAccessLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := nopLogger()
+	logger.Logger = zap.New(core)
+
+	engine := NewEngine()
+	engine.Use(RequestID(), AccessLog(AccessLogConfig{Logger: logger, SkipPaths: []string{"/healthz"}}))
+	engine.GET("/users/:id", func(ctx *Context) error {
+		ctx.AddError(errors.New("写入缓存失败"))
+		return ctx.WriteString(http.StatusOK, "ok")
+	})
+	engine.GET("/fail", func(ctx *Context) error {
+		return errors.New("数据库不可用")
+	})
+	engine.GET("/healthz", func(ctx *Context) error {
+		return ctx.WriteString(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(constants.TraceIdKey, "req-1")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	entries := logs.All()
+	if !assert.Len(t, entries, 2) {
+		return
+	}
+	ok := entries[0].ContextMap()
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "/users/42", ok["path"])
+	assert.Equal(t, "/users/:id", ok["route"])
+	assert.Equal(t, int64(http.StatusOK), ok["status"])
+	assert.Equal(t, int64(2), ok["size"])
+	assert.Equal(t, "req-1", ok[constants.TraceIdKey])
+	assert.Equal(t, []any{"写入缓存失败"}, ok["errors"])
+
+	failed := entries[1].ContextMap()
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, int64(http.StatusInternalServerError), failed["status"])
+	assert.Equal(t, []any{"数据库不可用"}, failed["errors"])
+}
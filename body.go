@@ -0,0 +1,222 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 12:31:44
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:42:48
+ * @FilePath: \gosh\body.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 常量定义
+const (
+	defaultBodySpillThreshold = 4 << 20 // 默认请求体超过 4 MB 时写入临时文件
+)
+
+// bodyCache 缓存的请求体，小请求体保存在内存中，大请求体写入临时文件
+type bodyCache struct {
+	data []byte   // 内存中的请求体
+	file *os.File // 溢出到磁盘的请求体
+	size int64    // 请求体大小
+}
+
+// reader 返回一个从头读取请求体的新 Reader
+func (b *bodyCache) reader() io.Reader {
+	if b.file != nil {
+		return io.NewSectionReader(b.file, 0, b.size)
+	}
+	return bytes.NewReader(b.data)
+}
+
+// bytes 返回完整的请求体
+func (b *bodyCache) bytes() ([]byte, error) {
+	if b.file == nil {
+		return b.data, nil
+	}
+	data := make([]byte, b.size)
+	if _, err := b.file.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// release 关闭并删除临时文件
+func (b *bodyCache) release() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+	b.data = nil
+}
+
+// BodyLimit 返回限制请求体大小的中间件，超过限制时返回 413
+// 可用于路由组覆盖 Config.MaxBodySize，限制值可以大于或小于全局配置
+func BodyLimit(limit int64) HandlerFunc {
+	return func(ctx *Context) error {
+		ctx.applyBodyLimit(limit)
+		return nil
+	}
+}
+
+// CacheBody 返回开启请求体缓存的中间件，开启后 Body、BodyReader 和 JSONParseBody 可以重复读取请求体
+// spillThreshold 指定写入临时文件的阈值，不传时使用 Config.BodySpillThreshold
+func CacheBody(spillThreshold ...int64) HandlerFunc {
+	return func(ctx *Context) error {
+		ctx.EnableBodyCache(spillThreshold...)
+		return nil
+	}
+}
+
+// EnableBodyCache 为当前请求开启请求体缓存
+func (ctx *Context) EnableBodyCache(spillThreshold ...int64) {
+	ctx.cacheBody = true
+	if len(spillThreshold) > 0 && spillThreshold[0] > 0 {
+		ctx.bodySpillThreshold = spillThreshold[0]
+	}
+}
+
+// applyBodyLimit 为请求体设置大小限制，超过限制时写出 413 并中止请求，返回是否可以继续处理
+func (ctx *Context) applyBodyLimit(limit int64) bool {
+	if limit <= 0 || ctx.Request.Body == nil {
+		return true
+	}
+	// 请求体已经被缓存时直接比较大小
+	if ctx.bodyCache != nil {
+		if ctx.bodyCache.size > limit {
+			ctx.respondBodyTooLarge(nil)
+			return false
+		}
+		return true
+	}
+	if ctx.Request.ContentLength > limit {
+		ctx.respondBodyTooLarge(nil)
+		return false
+	}
+
+	// 保留原始请求体，使路由组可以放宽全局限制
+	if ctx.rawBody == nil {
+		ctx.rawBody = ctx.Request.Body
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.ResponseWriter, ctx.rawBody, limit)
+	ctx.bodyLimit = limit
+	return true
+}
+
+// respondBodyTooLarge 按 errorsx.ErrBodyTooLarge 写出 413 响应并中止请求，cause 为读取请求体时的原始错误
+func (ctx *Context) respondBodyTooLarge(cause error) {
+	err := errorsx.ErrBodyTooLarge.WithSceneCode(int(BodyTooLarge))
+	if cause != nil {
+		err = err.Wrap(cause)
+	}
+	if ctx.Writer().Written() {
+		ctx.Abort()
+		ctx.Error = err
+		return
+	}
+	handleError(ctx, ctx.Engine, err, 0)
+}
+
+// isBodyTooLarge 判断错误是否由请求体大小限制(http.MaxBytesReader)引起
+// CustomError(例如上传限制错误)按自身的信息与状态码处理
+func isBodyTooLarge(err error) bool {
+	var customErr *errorsx.CustomError
+	if errors.As(err, &customErr) {
+		return false
+	}
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// bodyCacheEnabled 判断当前请求是否开启了请求体缓存
+func (ctx *Context) bodyCacheEnabled() bool {
+	return ctx.cacheBody || (ctx.Engine != nil && ctx.Engine.Config.CacheRequestBody)
+}
+
+// spillThreshold 返回写入临时文件的阈值
+func (ctx *Context) spillThreshold() int64 {
+	if ctx.bodySpillThreshold > 0 {
+		return ctx.bodySpillThreshold
+	}
+	if ctx.Engine != nil && ctx.Engine.Config.BodySpillThreshold > 0 {
+		return ctx.Engine.Config.BodySpillThreshold
+	}
+	return defaultBodySpillThreshold
+}
+
+// loadBodyCache 读取并缓存请求体，之后 Request.Body 会被替换为缓存的 Reader
+func (ctx *Context) loadBodyCache() error {
+	if ctx.bodyCache != nil {
+		ctx.Request.Body = io.NopCloser(ctx.bodyCache.reader())
+		return nil
+	}
+
+	cache := &bodyCache{}
+	if body := ctx.Request.Body; body != nil {
+		defer body.Close()
+
+		threshold := ctx.spillThreshold()
+		var buf bytes.Buffer
+		n, err := io.CopyN(&buf, body, threshold+1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if n <= threshold {
+			cache.data = buf.Bytes()
+			cache.size = n
+		} else {
+			// 超过阈值，将已读取的部分和剩余部分一起写入临时文件
+			file, err := os.CreateTemp("", "gosh-body-*")
+			if err != nil {
+				return err
+			}
+			cache.file = file
+			written, err := io.Copy(file, io.MultiReader(&buf, body))
+			if err != nil {
+				cache.release()
+				return err
+			}
+			cache.size = written
+		}
+	}
+
+	ctx.bodyCache = cache
+	ctx.Request.Body = io.NopCloser(cache.reader())
+	return nil
+}
+
+// BodyReader 返回请求体的 Reader，开启缓存时每次调用都会从头读取
+func (ctx *Context) BodyReader() (io.Reader, error) {
+	if !ctx.bodyCacheEnabled() {
+		return ctx.Request.Body, nil
+	}
+	if err := ctx.loadBodyCache(); err != nil {
+		return nil, err
+	}
+	return ctx.bodyCache.reader(), nil
+}
+
+// releaseBody 释放请求体缓存占用的资源
+func (ctx *Context) releaseBody() {
+	if ctx.bodyCache != nil {
+		ctx.bodyCache.release()
+		ctx.bodyCache = nil
+	}
+	ctx.rawBody = nil
+	ctx.bodyLimit = 0
+	ctx.cacheBody = false
+	ctx.bodySpillThreshold = 0
+}
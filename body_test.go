@@ -0,0 +1,150 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 12:58:20
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:31:23
+ * @FilePath: \gosh\body_test.go
+ * @Description: 测试请求体大小限制与缓存功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chunkedBody 去掉 Content-Length，模拟分块传输的请求体
+type chunkedBody struct{ io.Reader }
+
+func (chunkedBody) Close() error { return nil }
+
+// TestMaxBodySizeByContentLength 测试根据 Content-Length 直接拒绝
+func TestMaxBodySizeByContentLength(t *testing.T) {
+	called := false
+	engine := NewEngine(Config{MaxBodySize: 8})
+	engine.POST("/", func(ctx *Context) error {
+		called = true
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, float64(BodyTooLarge), resp["code"])
+	assert.Equal(t, "请求体超出大小限制", resp["message"])
+}
+
+// TestMaxBodySizeWhileReading 测试读取过程中超出限制
+func TestMaxBodySizeWhileReading(t *testing.T) {
+	engine := NewEngine(Config{MaxBodySize: 8})
+	engine.POST("/", func(ctx *Context) error {
+		_, err := ctx.Body()
+		return err
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Body = chunkedBody{strings.NewReader("0123456789")}
+	req.ContentLength = -1
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+// TestBodyLimitPerGroup 测试路由组覆盖全局限制
+func TestBodyLimitPerGroup(t *testing.T) {
+	engine := NewEngine(Config{MaxBodySize: 4})
+	upload := engine.Group("/upload", BodyLimit(16))
+	upload.POST("/file", func(ctx *Context) error {
+		body, err := ctx.Body()
+		if err != nil {
+			return err
+		}
+		return ctx.WriteString(http.StatusOK, string(body))
+	})
+	small := engine.Group("/small", BodyLimit(2))
+	small.POST("/file", func(ctx *Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodPost, "/upload/file", nil)
+	req.Body = chunkedBody{strings.NewReader("0123456789")}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "0123456789", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/small/file", strings.NewReader("abc")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+// TestCacheBodyReplay 测试请求体缓存后可以重复读取
+func TestCacheBodyReplay(t *testing.T) {
+	engine := NewEngine()
+	engine.POST("/", CacheBody(), func(ctx *Context) error {
+		var first, second map[string]string
+		assert.NoError(t, ctx.JSONParseBody(&first))
+		assert.NoError(t, ctx.JSONParseBody(&second))
+		assert.Equal(t, first, second)
+
+		body, err := ctx.Body()
+		assert.NoError(t, err)
+		raw, err := io.ReadAll(ctx.Request.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, raw)
+		return ctx.WriteString(http.StatusOK, first["name"])
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"gosh"}`)))
+	assert.Equal(t, "gosh", recorder.Body.String())
+}
+
+// TestCacheBodySpillToDisk 测试大请求体写入临时文件并在请求结束后删除
+func TestCacheBodySpillToDisk(t *testing.T) {
+	var spilled string
+	payload := strings.Repeat("x", 64)
+	engine := NewEngine(Config{CacheRequestBody: true, BodySpillThreshold: 16})
+	engine.POST("/", func(ctx *Context) error {
+		reader, err := ctx.BodyReader()
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(reader)
+		assert.Equal(t, payload, string(data))
+
+		assert.NotNil(t, ctx.bodyCache.file)
+		spilled = ctx.bodyCache.file.Name()
+		body, err := ctx.Body()
+		assert.NoError(t, err)
+		assert.Equal(t, payload, string(body))
+		return nil
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)))
+	_, err := os.Stat(spilled)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestCacheBodyWithForm 测试解析表单后仍可读取原始请求体
+func TestCacheBodyWithForm(t *testing.T) {
+	engine := NewEngine(Config{CacheRequestBody: true})
+	engine.POST("/", func(ctx *Context) error {
+		assert.Equal(t, "ok", ctx.FormValue("test"))
+		body, err := ctx.Body()
+		assert.NoError(t, err)
+		assert.Equal(t, "test=ok", string(body))
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("test=ok"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 08:59:07
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2025-01-20 17:56:01
+ * @LastEditTime: 2026-10-19 06:13:47
  * @FilePath: \gosh\config.go
  * @Description:
  *
@@ -19,6 +19,7 @@ func setDefaultConfig() Config {
 	config := Config{
 		MaxMultipartMemory:     defaultMaxMultipartMemory,
 		HandleMethodNotAllowed: false,
+		LanguageQueryKey:       defaultLanguageQueryKey,
 		AppBanner:              NewBannerConfig(),
 		KmSingleConfig: &goconfig.SingleConfig{
 			Zap: DefaultKmZipConfig(),
@@ -36,6 +37,11 @@ func mergeDefaultConfig(defaultConfig, customConfig Config) Config {
 		defaultConfig.Recovery = customConfig.Recovery
 	}
 
+	if customConfig.RecoveryHandler != nil {
+		defaultConfig.Recovery = true
+		defaultConfig.RecoveryHandler = customConfig.RecoveryHandler
+	}
+
 	if customConfig.HandleMethodNotAllowed {
 		defaultConfig.HandleMethodNotAllowed = customConfig.HandleMethodNotAllowed
 	}
@@ -72,10 +78,74 @@ func mergeDefaultConfig(defaultConfig, customConfig Config) Config {
 		}
 	}
 
+	if customConfig.Zap != nil {
+		defaultConfig.Zap = customConfig.Zap
+	}
+
 	if customConfig.KmSingleConfig == nil {
 		defaultConfig.KmSingleConfig.Zap = DefaultKmZipConfig()
 	}
 
+	if customConfig.Hub != nil {
+		defaultConfig.Hub = customConfig.Hub
+	}
+
+	if len(customConfig.TrustedProxies) > 0 {
+		defaultConfig.TrustedProxies = customConfig.TrustedProxies
+	}
+
+	if len(customConfig.TrustedHeaders) > 0 {
+		defaultConfig.TrustedHeaders = customConfig.TrustedHeaders
+	}
+
+	if customConfig.MaxBodySize > 0 {
+		defaultConfig.MaxBodySize = customConfig.MaxBodySize
+	}
+
+	if customConfig.CacheRequestBody {
+		defaultConfig.CacheRequestBody = customConfig.CacheRequestBody
+	}
+
+	if customConfig.BodySpillThreshold > 0 {
+		defaultConfig.BodySpillThreshold = customConfig.BodySpillThreshold
+	}
+
+	if len(customConfig.SecretKeys) > 0 {
+		defaultConfig.SecretKeys = customConfig.SecretKeys
+	}
+
+	if customConfig.ProblemDetails {
+		defaultConfig.ProblemDetails = customConfig.ProblemDetails
+	}
+
+	if customConfig.ProblemTypeBase != "" {
+		defaultConfig.ProblemTypeBase = customConfig.ProblemTypeBase
+	}
+
+	if customConfig.ErrorPage != nil {
+		defaultConfig.ErrorPage = customConfig.ErrorPage
+	}
+
+	if customConfig.Envelope != nil {
+		defaultConfig.Envelope = customConfig.Envelope
+	}
+
+	if customConfig.Pagination != nil {
+		defaultConfig.Pagination = customConfig.Pagination
+	}
+
+	if customConfig.Trans != nil {
+		defaultConfig.Trans = customConfig.Trans
+	}
+
+	if customConfig.Validator != nil {
+		defaultConfig.Validator = customConfig.Validator
+	}
+
+	if customConfig.LanguageQueryKey != "" {
+		defaultConfig.LanguageQueryKey = customConfig.LanguageQueryKey
+	}
+
 	if customConfig.AppName != "" {
 		defaultConfig.AppName = customConfig.AppName
 	}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 21:19:05
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-11 21:21:30
+ * @LastEditTime: 2026-10-19 06:00:15
  * @FilePath: \go-wine\constants\content.go
  * @Description:
  *
@@ -12,8 +12,21 @@ package constants
 
 // ContentType 相关常量
 const (
-	ContentTypeJSON  = "application/json; charset=utf-8"
-	ContentTypePlain = "text/plain; charset=utf-8"
-	ContentTypeHtml  = "text/html"
-	ContentTypeOctet = "application/octet-stream"
+	ContentTypeJSON        = "application/json; charset=utf-8"
+	ContentTypeProblemJSON = "application/problem+json; charset=utf-8"
+	ContentTypePlain       = "text/plain; charset=utf-8"
+	ContentTypeHtml        = "text/html"
+	ContentTypeHtmlUTF8    = "text/html; charset=utf-8"
+	ContentTypeOctet       = "application/octet-stream"
+	ContentTypeEventStream = "text/event-stream"
+	ContentTypeOffsetOctet = "application/offset+octet-stream"
+	ContentTypeZip         = "application/zip"
+)
+
+// MIME 类型常量，用于内容协商
+const (
+	MIMEJSON        = "application/json"
+	MIMEProblemJSON = "application/problem+json"
+	MIMEHTML        = "text/html"
+	MIMEPlain       = "text/plain"
 )
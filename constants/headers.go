@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 21:19:15
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2025-01-20 10:15:55
+ * @LastEditTime: 2026-10-19 06:13:47
  * @FilePath: \gosh\constants\headers.go
  * @Description:
  *
@@ -12,11 +12,65 @@ package constants
 
 // HTTP 头部相关关键字常量
 const (
-	HeaderContentTypeKey     = "Content-Type"
-	HeaderLocationKey        = "Location"
-	HeaderContentLengthKey   = "Content-Length"
-	HeaderContentEncodingKey = "Content-Encoding"
-	HeaderOriginKey          = "Origin"
+	HeaderContentTypeKey        = "Content-Type"
+	HeaderLocationKey           = "Location"
+	HeaderContentLengthKey      = "Content-Length"
+	HeaderContentEncodingKey    = "Content-Encoding"
+	HeaderOriginKey             = "Origin"
+	HeaderUpgradeKey            = "Upgrade"
+	HeaderConnectionKey         = "Connection"
+	HeaderHostKey               = "Host"
+	HeaderCacheControlKey       = "Cache-Control"
+	HeaderETagKey               = "ETag"
+	HeaderContentDispositionKey = "Content-Disposition"
+	HeaderAcceptKey             = "Accept"
+	HeaderAcceptLanguageKey     = "Accept-Language"
+	HeaderLinkKey               = "Link"
+)
+
+// 代理转发相关的常量
+const (
+	HeaderXForwardedForKey   = "X-Forwarded-For"
+	HeaderXForwardedProtoKey = "X-Forwarded-Proto"
+	HeaderXForwardedHostKey  = "X-Forwarded-Host"
+	HeaderXRealIPKey         = "X-Real-IP"
+	HeaderForwardedKey       = "Forwarded"
+	HeaderCFConnectingIPKey  = "CF-Connecting-IP"
+	HeaderTrueClientIPKey    = "True-Client-IP"
+	HeaderFastlyClientIPKey  = "Fastly-Client-IP"
+)
+
+// WebSocket 相关的常量
+const (
+	HeaderSecWebSocketKey        = "Sec-WebSocket-Key"
+	HeaderSecWebSocketAccept     = "Sec-WebSocket-Accept"
+	HeaderSecWebSocketVersion    = "Sec-WebSocket-Version"
+	HeaderSecWebSocketProtocol   = "Sec-WebSocket-Protocol"
+	HeaderSecWebSocketExtensions = "Sec-WebSocket-Extensions"
+	WebSocketVersion             = "13"
+	WebSocketUpgradeToken        = "websocket"
+	WebSocketExtensionDeflate    = "permessage-deflate"
+)
+
+// tus 断点续传协议相关的常量
+const (
+	HeaderTusResumableKey         = "Tus-Resumable"
+	HeaderTusVersionKey           = "Tus-Version"
+	HeaderTusExtensionKey         = "Tus-Extension"
+	HeaderTusMaxSizeKey           = "Tus-Max-Size"
+	HeaderTusChecksumAlgorithmKey = "Tus-Checksum-Algorithm"
+	HeaderUploadOffsetKey         = "Upload-Offset"
+	HeaderUploadLengthKey         = "Upload-Length"
+	HeaderUploadMetadataKey       = "Upload-Metadata"
+	HeaderUploadExpiresKey        = "Upload-Expires"
+	HeaderUploadChecksumKey       = "Upload-Checksum"
+	TusVersion                    = "1.0.0"
+)
+
+// W3C Trace Context 相关的常量
+const (
+	HeaderTraceParentKey = "traceparent"
+	HeaderTraceStateKey  = "tracestate"
 )
 
 // ContentEncoding 相关的常量
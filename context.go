@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 08:59:07
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-17 22:36:15
+ * @LastEditTime: 2026-10-19 06:41:44
  * @FilePath: \gosh\context.go
  * @Description:
  *
@@ -12,17 +12,16 @@ package gosh
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
 	"io"
-	"mime"
 	"mime/multipart"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/kamalyes/go-toolbox/pkg/convert"
@@ -44,6 +43,9 @@ type ContextInterface interface {
 
 	// 上下文处理
 	SetContextValue(key, value any) // 设置上下文中的值
+	Set(key string, value any)      // 设置请求级别的键值
+	Get(key string) (any, bool)     // 获取请求级别的键值
+	MustGet(key string) any         // 获取请求级别的键值，不存在时 panic
 	GetContextValue(key any) any    // 获取上下文中的值
 	Deadline() (time.Time, bool)    // Deadline 返回请求的截止时间和一个布尔值，表示是否存在截止时间。如果请求没有上下文，则返回零值和 false。
 	Done() <-chan struct{}          // Done 返回一个通道，当请求的上下文完成时，该通道会关闭。如果请求没有上下文，则返回 nil（表示永远不会完成）。
@@ -66,6 +68,8 @@ type ContextInterface interface {
 	ServeFile(filePath string) error                                                    // 提供指定路径的文件
 	FileFromFS(filePath string, fs http.FileSystem) error                               // 从文件系统提供文件
 	SaveFile(fileHeader *multipart.FileHeader, savePath string, perm os.FileMode) error // 保存上传的文件
+	Attachment(filePath, filename string) error                                         // 以附件形式下载文件
+	StreamZip(filename string, entries []ZipEntry) error                                // 边打包边下载 zip 压缩包
 
 	// 请求处理
 	Method() string           // 获取请求方法
@@ -78,6 +82,13 @@ type ContextInterface interface {
 	// Cookie 和请求体处理
 	SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) // 设置 Cookie
 	Cookie(name string) (string, error)                                                   // 获取 Cookie
+	SetCookieWithOptions(name, value string, options ...CookieOptions) error              // 按选项设置 Cookie
+	DeleteCookie(name string, options ...CookieOptions)                                   // 删除 Cookie
+	SetSignedCookie(name, value string, options ...CookieOptions) error                   // 设置签名 Cookie
+	SignedCookie(name string) (string, error)                                             // 获取并验证签名 Cookie
+	SetEncryptedCookie(name, value string, options ...CookieOptions) error                // 设置加密 Cookie
+	EncryptedCookie(name string) (string, error)                                          // 获取并解密加密 Cookie
+	Session() *Session                                                                    // 获取当前会话
 	Body() ([]byte, error)                                                                // 获取请求体内容
 	JSONParseBody(obj any) error                                                          // 获取 Json请求体
 	IsMethod(method string) bool                                                          // 检查请求方法是否为指定的方法
@@ -101,6 +112,25 @@ type Context struct {
 	queryCache     url.Values          // 查询参数缓存
 	formCache      url.Values          // 表单参数缓存
 	handlers       HandlersChain       // 处理程序链
+	writermem      responseWriter      // 复用的响应写入器包装
+	sessionState   *sessionState       // 会话中间件状态
+	timedOut       bool                // 是否因超时中间件返回了 503
+	requestID      string              // 请求 ID
+	logger         *Logger             // 绑定了请求 ID 的日志记录器
+	span           *Span               // 当前处理程序对应的链路 Span
+	errs           ContextErrors       // 请求处理过程中收集的错误，与 Keys 共用锁
+	language       string              // 通过 SetLanguage 指定的语言
+
+	Keys          map[string]any // 请求级别的键值存储，建议通过 Set/Get 读写
+	contextValues map[any]any    // 通过 SetContextValue 设置的非字符串键
+	keysMu        *sync.RWMutex  // 保护 Keys 与 contextValues，使用指针避免复制 Context 时复制锁
+	keysInstalled bool           // 是否已将键值视图挂载到请求上下文
+
+	rawBody            io.ReadCloser // 设置大小限制前的原始请求体
+	bodyLimit          int64         // 当前生效的请求体大小限制
+	cacheBody          bool          // 是否缓存请求体
+	bodySpillThreshold int64         // 请求体写入临时文件的阈值
+	bodyCache          *bodyCache    // 缓存的请求体
 }
 
 // 实现 ContextInterface
@@ -116,8 +146,20 @@ func (ctx *Context) reset() {
 	ctx.fullPath = ""                           // 清空完整路径
 	ctx.queryCache = nil                        // 清空查询参数缓存
 	ctx.formCache = nil                         // 清空表单参数缓存
+	ctx.handlers = nil                          // 清空处理程序链
+	ctx.sessionState = nil                      // 清空会话状态
+	ctx.timedOut = false                        // 清空超时标志
+	ctx.requestID = ""                          // 清空请求 ID
+	ctx.logger = nil                            // 清空日志记录器
+	ctx.span = nil                              // 清空链路 Span
+	ctx.errs = ctx.errs[:0]                     // 清空收集的错误
+	ctx.language = ""                           // 清空指定的语言
+	ctx.Keys = nil                              // 清空键值存储
+	ctx.contextValues = nil                     // 清空上下文值
+	ctx.keysInstalled = false                   // 下次写入时重新挂载上下文视图
 	*ctx.params = (*ctx.params)[:0]             // 清空路径参数
 	*ctx.skippedNodes = (*ctx.skippedNodes)[:0] // 清空被跳过的节点
+	ctx.releaseBody()                           // 释放请求体缓存
 }
 
 // 获取引擎配置
@@ -130,6 +172,15 @@ func (ctx *Context) FullPath() string {
 	return ctx.fullPath // 返回请求的完整路径
 }
 
+// RouteMeta 返回当前路由通过 RouterGroup.WithMeta 注册的元数据
+func (ctx *Context) RouteMeta(key string) (value any, exists bool) {
+	if ctx.Engine == nil || ctx.Request == nil {
+		return nil, false
+	}
+	value, exists = ctx.Engine.routeMeta[ctx.Request.Method+" "+ctx.fullPath][key]
+	return
+}
+
 // 停止当前请求的处理
 func (ctx *Context) Abort() *Context {
 	ctx.broke = true // 将请求标记为已中止
@@ -177,26 +228,43 @@ func (c *Context) AbortWithError(code int, err error) error {
 	return err              // 记录错误信息
 }
 
-// 执行下一个处理程序
+// Next 执行处理程序链中剩余的处理程序，中间件可以借此在后续处理程序执行完之后再做处理
+// 处理程序返回错误时交给引擎统一处理，并停止执行后续处理程序
 func (ctx *Context) Next() {
 	ctx.index++                               // 增加处理程序索引
 	for ctx.index < int8(len(ctx.handlers)) { // 遍历处理程序链
-		ctx.handlers[ctx.index](ctx) // 执行当前处理程序
-		ctx.index++                  // 移动到下一个处理程序
+		if ctx.broke {
+			return
+		}
+		if err := ctx.runHandler(ctx.handlers[ctx.index]); err != nil { // 执行当前处理程序
+			ctx.Engine.handleError(ctx, err)
+			return
+		}
+		ctx.index++ // 移动到下一个处理程序
 	}
 }
 
 // SetContextValue 设置上下文中的值
+// 字符串键与 Set 共用同一个存储，值同时可以通过 Request.Context().Value(key) 读取
 func (ctx *Context) SetContextValue(key, value any) {
-	if key != nil {
-		ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), key, value))
+	if key == nil {
+		return
+	}
+	if k, ok := key.(string); ok {
+		ctx.Set(k, value)
+		return
 	}
+	mu := ctx.keysLock()
+	mu.Lock()
+	defer mu.Unlock()
+	ctx.ensureKeys()
+	ctx.contextValues[key] = value
 }
 
 // 从上下文中获取值
 func (ctx *Context) GetContextValue(key any) any {
 	if key != nil {
-		return ctx.Request.Context().Value(key) // 从上下文中获取指定键的值
+		return ctx.Value(key) // 从上下文中获取指定键的值
 	}
 	return nil
 }
@@ -244,6 +312,10 @@ func (c *Context) Copy() *Context {
 		fullPath:   c.fullPath,       // 复制完整路径
 		Engine:     c.Engine,         // 复制引擎
 		params:     c.params,         // 复制路径参数
+		requestID:  c.requestID,      // 复制请求 ID
+		logger:     c.logger,         // 复制日志记录器
+		span:       c.span,           // 复制链路 Span
+		language:   c.language,       // 复制指定的语言
 		queryCache: make(url.Values), // 创建新的查询参数缓存
 		formCache:  make(url.Values), // 创建新的表单参数缓存
 		handlers:   nil,              // 清空处理程序链
@@ -266,6 +338,26 @@ func (c *Context) Copy() *Context {
 		copy(*cp.skippedNodes, *c.skippedNodes)
 	}
 
+	// 复制键值存储
+	mu := c.keysLock()
+	mu.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]any, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	if c.contextValues != nil {
+		cp.contextValues = make(map[any]any, len(c.contextValues))
+		for k, v := range c.contextValues {
+			cp.contextValues[k] = v
+		}
+	}
+	if len(c.errs) > 0 {
+		cp.errs = append(ContextErrors(nil), c.errs...)
+	}
+	mu.RUnlock()
+
 	// 复制其他字段（如需要）
 	cp.handlers = append([]HandlerFunc{}, c.handlers...) // 复制处理程序链
 
@@ -323,8 +415,15 @@ func (ctx *Context) InitFormCache() error {
 	}
 	ctx.formCache = make(url.Values) // 创建新的表单参数缓存
 
+	// 开启请求体缓存时先缓存，保证解析表单后仍可读取原始请求体
+	if ctx.bodyCacheEnabled() {
+		if err := ctx.loadBodyCache(); err != nil {
+			return err
+		}
+	}
+
 	// 解析请求的表单数据
-	if err := ctx.Request.ParseMultipartForm(ctx.Engine.Config.MaxMultipartMemory); err != nil && !errors.Is(err, errorsx.ErrNotMultipart) {
+	if err := ctx.Request.ParseMultipartForm(ctx.Engine.Config.MaxMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
 		return err // 如果解析失败，返回错误
 	}
 	ctx.formCache = ctx.Request.PostForm // 将解析的表单数据存入缓存
@@ -349,6 +448,11 @@ func (ctx *Context) AllFormValues() url.Values {
 
 // MultipartForm 是解析后的多部分表单，包括文件上传。
 func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if c.bodyCacheEnabled() {
+		if err := c.loadBodyCache(); err != nil {
+			return nil, err
+		}
+	}
 	err := c.Request.ParseMultipartForm(c.Engine.Config.MaxMultipartMemory)
 	return c.Request.MultipartForm, err
 }
@@ -368,37 +472,47 @@ func (ctx *Context) FormFile(name string) (*multipart.FileHeader, error) {
 }
 
 // 提供文件的公共逻辑
-func (ctx *Context) serveFileResponse(file io.Reader, fileInfo os.FileInfo) error {
-	contentType := constants.ContentTypeOctet // 默认内容类型
-	if ext := filepath.Ext(fileInfo.Name()); ext != "" {
-		contentType = mime.TypeByExtension(ext) // 根据文件扩展名设置内容类型
+// 基于 http.ServeContent 实现：生成 ETag 与 Last-Modified，处理 If-Match、If-None-Match、If-Modified-Since、
+// If-Range 等条件请求返回 304/412，处理单个或多个 Range 返回 206，
+// 未设置 Content-Type 时先按扩展名推断，推断不出再嗅探文件内容
+func (ctx *Context) serveFileResponse(content io.ReadSeeker, fileInfo os.FileInfo) error {
+	if fileInfo.IsDir() {
+		return errorsx.ErrFileNotFound // 目录不能作为文件提供，由引擎统一返回 404
+	}
+	if ctx.ResponseWriter.Header().Get(constants.HeaderETagKey) == "" {
+		ctx.SetHeader(constants.HeaderETagKey, fileETag(fileInfo))
 	}
-	// 设置 Content-Type 为文本
-	ctx.setContentType(contentType)
-	// 设置 Content-Length 头部
-	ctx.setHeaderContentLength(convert.MustString(fileInfo.Size()))
 
-	// 写入响应头
-	ctx.ResponseWriter.WriteHeader(http.StatusOK) // 设置响应状态为 200 OK
+	http.ServeContent(ctx.ResponseWriter, ctx.Request, fileInfo.Name(), fileInfo.ModTime(), content)
+	ctx.Status = ctx.Writer().Status() // 记录实际写出的状态码（200、206、304 等）
+	return nil
+}
 
-	// 将文件内容写入响应
-	_, err := io.Copy(ctx.ResponseWriter, file) // 将文件内容拷贝到响应写入器
-	return err
+// fileETag 根据文件大小与修改时间生成强 ETag，文件变化后 ETag 随之变化
+// 使用强 ETag 才能让 If-Range 生效
+func fileETag(fileInfo os.FileInfo) string {
+	return `"` + strconv.FormatInt(fileInfo.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(fileInfo.Size(), 16) + `"`
+}
+
+// fileError 把打开文件的错误转换为 CustomError：文件不存在时为 404，其他错误为 500
+func fileError(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return errorsx.ErrFileNotFound.Wrap(err)
+	}
+	return errorsx.ErrInternalServerError.Wrap(err)
 }
 
 // ServeFile 提供文件给客户端并设置适当的 Content-Type 头部
 func (ctx *Context) ServeFile(filePath string) error {
 	file, err := os.Open(filePath) // 打开指定路径的文件
 	if err != nil {
-		ctx.ResponseWriter.WriteHeader(http.StatusNotFound) // 如果文件未找到，返回 404
-		return err
+		return fileError(err) // 由引擎统一写出 404 或 500
 	}
 	defer file.Close()
 
 	fileInfo, err := file.Stat() // 获取文件信息
 	if err != nil {
-		ctx.ResponseWriter.WriteHeader(http.StatusInternalServerError) // 如果获取文件信息失败，返回 500
-		return err
+		return fileError(err)
 	}
 
 	return ctx.serveFileResponse(file, fileInfo) // 调用公共方法返回文件内容
@@ -408,15 +522,13 @@ func (ctx *Context) ServeFile(filePath string) error {
 func (ctx *Context) FileFromFS(filePath string, fs http.FileSystem) error {
 	file, err := fs.Open(filePath) // 从文件系统打开文件
 	if err != nil {
-		ctx.ResponseWriter.WriteHeader(http.StatusNotFound) // 如果文件未找到，返回 404
-		return err
+		return fileError(err) // 由引擎统一写出 404 或 500
 	}
 	defer file.Close()
 
 	fileInfo, err := file.Stat() // 获取文件信息
 	if err != nil {
-		ctx.ResponseWriter.WriteHeader(http.StatusInternalServerError) // 如果获取文件信息失败，返回 500
-		return err
+		return fileError(err)
 	}
 
 	return ctx.serveFileResponse(file, fileInfo) // 调用公共方法返回文件内容
@@ -465,6 +577,7 @@ func WrapH(h http.Handler) HandlerFunc {
 
 // 响应处理
 func (ctx *Context) WriteString(status int, data string) error {
+	ctx.Status = status                                                 // 记录状态码
 	ctx.setContentType(constants.ContentTypePlain)                      // 设置 Content-Type 为文本
 	ctx.ResponseWriter.WriteHeader(status)                              // 写入响应状态码
 	_, err := ctx.ResponseWriter.Write(convert.StringToSliceByte(data)) // 将字符串写入响应
@@ -477,6 +590,7 @@ func (ctx *Context) WriteJSONResponse(status int, data any) error {
 	if err != nil {
 		return err // 返回错误信息
 	}
+	ctx.Status = status                           // 记录状态码
 	ctx.setContentType(constants.ContentTypeJSON) // 设置 Content-Type 为 JSON
 	// ctx.ResponseWriter.Header().Set(constants.HeaderContentLength, strconv.Itoa(len(buf))) // 设置 Content-Length
 	ctx.ResponseWriter.WriteHeader(status) // 写入响应状态码
@@ -504,7 +618,7 @@ func (ctx *Context) WriteRedirect(code int, url string) error {
 
 // Json解析请求Body数据
 func (ctx *Context) JSONParseBody(obj any) error {
-	body, err := io.ReadAll(ctx.Request.Body) // 读取请求体
+	body, err := ctx.Body() // 读取请求体，读取后请求体仍可再次读取
 	if err != nil {
 		return err // 返回错误信息
 	}
@@ -531,15 +645,6 @@ func (ctx *Context) AllHeaders() http.Header {
 	return ctx.Request.Header // 返回所有请求头
 }
 
-// 获取请求的客户端 IP 地址
-func (ctx *Context) ClientIP() string {
-	ip := ctx.Request.Header.Get("X-Forwarded-For") // 尝试从 X-Forwarded-For 头获取 IP
-	if ip == "" {
-		ip, _, _ = net.SplitHostPort(ctx.Request.RemoteAddr) // 如果没有，则从 RemoteAddr 获取
-	}
-	return ip // 返回客户端 IP 地址
-}
-
 // 获取请求的 User-Agent
 func (ctx *Context) UserAgent() string {
 	return ctx.Request.UserAgent() // 返回请求的 User-Agent
@@ -592,7 +697,15 @@ func (c *Context) Cookie(name string) (string, error) {
 }
 
 // Body 获取请求体内容
+// 开启请求体缓存时从缓存读取（大请求体可能位于临时文件中），否则读取后重新设置请求体
 func (ctx *Context) Body() ([]byte, error) {
+	if ctx.bodyCacheEnabled() {
+		if err := ctx.loadBodyCache(); err != nil {
+			return nil, err
+		}
+		return ctx.bodyCache.bytes()
+	}
+
 	body, err := io.ReadAll(ctx.Request.Body)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,177 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 22:06:45
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 22:06:45
+ * @FilePath: \gosh\context_errors.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// ContextError 请求处理过程中收集的错误，带有错误类型与附加信息
+type ContextError struct {
+	Err  error             // 原始错误
+	Type errorsx.ErrorType // 错误类型，可以按位组合
+	Meta any               // 附加信息，map[string]any 会在 JSON 中展开
+}
+
+// Error 实现 error 接口
+func (e *ContextError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// SetType 设置错误类型
+func (e *ContextError) SetType(errorType errorsx.ErrorType) *ContextError {
+	e.Type = errorType
+	return e
+}
+
+// SetMeta 设置附加信息
+func (e *ContextError) SetMeta(meta any) *ContextError {
+	e.Meta = meta
+	return e
+}
+
+// IsType 判断错误是否属于给定的类型掩码
+func (e *ContextError) IsType(errorType errorsx.ErrorType) bool {
+	return e.Type&errorType != 0
+}
+
+// JSON 返回可以返回给客户端的表示：error 为对外信息，附加信息展开或放在 meta 中
+func (e *ContextError) JSON() map[string]any {
+	out := make(map[string]any)
+	if meta, ok := e.Meta.(map[string]any); ok {
+		for key, value := range meta {
+			out[key] = value
+		}
+	} else if e.Meta != nil {
+		out["meta"] = e.Meta
+	}
+	out["error"] = e.publicMessage()
+	return out
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (e *ContextError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.JSON())
+}
+
+// publicMessage CustomError 只使用对外信息，不暴露内部原因
+func (e *ContextError) publicMessage() string {
+	var customErr *errorsx.CustomError
+	if errors.As(e.Err, &customErr) && customErr.IsPublic() {
+		return customErr.PublicMessage()
+	}
+	return e.Err.Error()
+}
+
+// ContextErrors 请求处理过程中收集的错误列表
+type ContextErrors []*ContextError
+
+// ByType 返回属于给定类型掩码的错误，例如 ByType(errorsx.ErrorTypePublic)
+func (list ContextErrors) ByType(errorType errorsx.ErrorType) ContextErrors {
+	if errorType == errorsx.ErrorTypeAny {
+		return list
+	}
+	var out ContextErrors
+	for _, err := range list {
+		if err.IsType(errorType) {
+			out = append(out, err)
+		}
+	}
+	return out
+}
+
+// Last 返回最后一个错误，列表为空时返回 nil
+func (list ContextErrors) Last() *ContextError {
+	if len(list) == 0 {
+		return nil
+	}
+	return list[len(list)-1]
+}
+
+// Errors 返回所有错误的信息
+func (list ContextErrors) Errors() []string {
+	out := make([]string, 0, len(list))
+	for _, err := range list {
+		out = append(out, err.Error())
+	}
+	return out
+}
+
+// JSON 返回所有错误的 JSON 表示，列表为空时返回 nil
+// 返回给客户端前应当先通过 ByType(errorsx.ErrorTypePublic) 过滤
+func (list ContextErrors) JSON() []map[string]any {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]map[string]any, 0, len(list))
+	for _, err := range list {
+		out = append(out, err.JSON())
+	}
+	return out
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (list ContextErrors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(list.JSON())
+}
+
+// String 返回便于日志输出的多行文本
+func (list ContextErrors) String() string {
+	var builder strings.Builder
+	for i, err := range list {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString("#")
+		builder.WriteString(strconv.Itoa(i + 1))
+		builder.WriteString(" ")
+		builder.WriteString(err.Error())
+	}
+	return builder.String()
+}
+
+// AddError 记录一个不中断请求的错误，返回的记录可以继续设置类型与附加信息
+// CustomError 默认使用自身的错误类型，其他错误默认为私有错误；可以在多个 goroutine 中调用
+func (ctx *Context) AddError(err error) *ContextError {
+	if err == nil {
+		return nil
+	}
+	entry, ok := err.(*ContextError)
+	if !ok {
+		entry = &ContextError{Err: err, Type: errorsx.From(err).ErrorType}
+	}
+	mu := ctx.keysLock()
+	mu.Lock()
+	ctx.errs = append(ctx.errs, entry)
+	mu.Unlock()
+	return entry
+}
+
+// Errors 返回当前请求收集的错误，包括处理程序返回而由引擎处理的错误
+func (ctx *Context) Errors() ContextErrors {
+	mu := ctx.keysLock()
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(ctx.errs) == 0 {
+		return nil
+	}
+	return append(ContextErrors(nil), ctx.errs...)
+}
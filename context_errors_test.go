@@ -0,0 +1,81 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 22:31:12
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 22:31:12
+ * @FilePath: \gosh\context_errors_test.go
+ * @Description: 测试上下文错误列表功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestContextErrors 测试收集错误、按类型过滤与 JSON 序列化
+func TestContextErrors(t *testing.T) {
+	ctx := &Context{}
+	assert.Nil(t, ctx.AddError(nil))
+	assert.Nil(t, ctx.Errors())
+
+	ctx.AddError(errors.New("写入缓存失败"))
+	ctx.AddError(errorsx.New(http.StatusBadRequest, "昵称过长").Wrap(errors.New("len=65"))).
+		SetMeta(map[string]any{"field": "nickname"})
+	ctx.AddError(errors.New("头像格式不支持")).SetType(errorsx.ErrorTypePublic | errorsx.ErrorTypeBind).SetMeta("avatar")
+
+	all := ctx.Errors()
+	assert.Len(t, all, 3)
+	assert.Equal(t, "头像格式不支持", all.Last().Error())
+	assert.Equal(t, all, all.ByType(errorsx.ErrorTypeAny))
+	assert.Equal(t, []string{"写入缓存失败"}, all.ByType(errorsx.ErrorTypePrivate).Errors())
+	assert.Len(t, all.ByType(errorsx.ErrorTypeBind), 1)
+	assert.Equal(t, "#1 写入缓存失败\n#2 昵称过长: len=65\n#3 头像格式不支持", all.String())
+
+	// 公开错误只输出对外信息，不包含内部原因
+	data, err := json.Marshal(all.ByType(errorsx.ErrorTypePublic))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"error":"昵称过长","field":"nickname"},{"error":"头像格式不支持","meta":"avatar"}]`, string(data))
+	assert.Nil(t, all.ByType(errorsx.ErrorTypeRender).JSON())
+
+	// 副本拥有独立的错误列表
+	cp := ctx.Copy()
+	cp.AddError(errors.New("仅副本"))
+	assert.Len(t, ctx.Errors(), 3)
+	assert.Len(t, cp.Errors(), 4)
+}
+
+// TestContextErrorsInRequest 测试并发收集错误，处理程序返回的错误也会被记录
+func TestContextErrorsInRequest(t *testing.T) {
+	var collected ContextErrors
+	engine := NewEngine()
+	engine.Use(func(ctx *Context) error {
+		ctx.Next()
+		collected = ctx.Errors()
+		return nil
+	})
+	engine.GET("/", func(ctx *Context) error {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx.AddError(errors.New("下游超时"))
+			}()
+		}
+		wg.Wait()
+		return errorsx.ErrAccessDenied
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, collected, 9)
+	assert.Len(t, collected.ByType(errorsx.ErrorTypePrivate), 8)
+	assert.True(t, errors.Is(collected.Last(), errorsx.ErrAccessDenied))
+}
@@ -0,0 +1,186 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 13:34:51
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 13:34:51
+ * @FilePath: \gosh\cookie.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 常量定义
+const (
+	maxCookieSize        = 4096             // 浏览器允许的单个 Cookie 最大长度
+	cookieSignPurpose    = "cookie-sign"    // Cookie 签名的密钥用途
+	cookieEncryptPurpose = "cookie-encrypt" // Cookie 加密的密钥用途
+)
+
+// CookieOptions Cookie 属性，与 http.Cookie 中的同名字段含义一致
+type CookieOptions struct {
+	Path     string        // 路径，默认为 "/"
+	Domain   string        // 域名
+	MaxAge   int           // 有效期（秒），0 表示会话 Cookie，负数表示立即删除
+	Expires  time.Time     // 过期时间，MaxAge 不为 0 时以 MaxAge 为准
+	Secure   bool          // 是否只在 HTTPS 下发送
+	HttpOnly bool          // 是否禁止脚本访问
+	SameSite http.SameSite // 跨站策略，默认为 Lax；设置为 None 时会强制开启 Secure
+}
+
+// newCookie 按选项创建 http.Cookie
+func newCookie(name, value string, options []CookieOptions) *http.Cookie {
+	var opt CookieOptions
+	if len(options) > 0 {
+		opt = options[0]
+	}
+	if opt.Path == "" {
+		opt.Path = "/"
+	}
+	if opt.SameSite == 0 {
+		opt.SameSite = http.SameSiteLaxMode
+	}
+	if opt.SameSite == http.SameSiteNoneMode {
+		opt.Secure = true // 浏览器会拒绝没有 Secure 的 SameSite=None
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opt.Path,
+		Domain:   opt.Domain,
+		MaxAge:   opt.MaxAge,
+		Expires:  opt.Expires,
+		Secure:   opt.Secure,
+		HttpOnly: opt.HttpOnly,
+		SameSite: opt.SameSite,
+	}
+}
+
+// cookieExpiry 返回写入签名或密文中的过期时间（Unix 秒），0 表示不过期
+func cookieExpiry(cookie *http.Cookie) int64 {
+	switch {
+	case cookie.MaxAge > 0:
+		return time.Now().Unix() + int64(cookie.MaxAge)
+	case !cookie.Expires.IsZero():
+		return cookie.Expires.Unix()
+	}
+	return 0
+}
+
+// writeCookie 检查长度后写入 Set-Cookie 响应头
+func (ctx *Context) writeCookie(cookie *http.Cookie) error {
+	if len(cookie.String()) > maxCookieSize {
+		return errorsx.ErrCookieTooLarge
+	}
+	http.SetCookie(ctx.ResponseWriter, cookie)
+	return nil
+}
+
+// SetCookieWithOptions 按选项设置 Cookie，值会被 URL 编码，与 Cookie 的解码对应
+func (ctx *Context) SetCookieWithOptions(name, value string, options ...CookieOptions) error {
+	return ctx.writeCookie(newCookie(name, url.QueryEscape(value), options))
+}
+
+// DeleteCookie 删除 Cookie，Path 与 Domain 需要与设置时一致
+func (ctx *Context) DeleteCookie(name string, options ...CookieOptions) {
+	cookie := newCookie(name, "", options)
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	http.SetCookie(ctx.ResponseWriter, cookie)
+}
+
+// encodeCookiePayload 将过期时间与值编码在一起：8 字节过期时间 + 值
+func encodeCookiePayload(value string, expires int64) []byte {
+	payload := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), uint64(expires))
+	return append(payload, value...)
+}
+
+// decodeCookiePayload 解析载荷并检查是否过期
+func decodeCookiePayload(payload []byte) (string, error) {
+	if len(payload) < 8 {
+		return "", errorsx.ErrCookieTampered
+	}
+	expires := int64(binary.BigEndian.Uint64(payload))
+	if expires > 0 && time.Now().Unix() >= expires {
+		return "", errorsx.ErrCookieExpired
+	}
+	return string(payload[8:]), nil
+}
+
+// SetSignedCookie 设置带 HMAC-SHA256 签名的 Cookie，值本身可读但无法被篡改
+// Cookie 名称参与签名，签名值无法被挪用到其它 Cookie 上
+func (ctx *Context) SetSignedCookie(name, value string, options ...CookieOptions) error {
+	cookie := newCookie(name, "", options)
+	payload := base64.RawURLEncoding.EncodeToString(encodeCookiePayload(value, cookieExpiry(cookie)))
+	signature, err := ctx.Engine.KeyRing().Sign(cookieSignPurpose, []byte(name+"="+payload))
+	if err != nil {
+		return err
+	}
+	cookie.Value = payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return ctx.writeCookie(cookie)
+}
+
+// SignedCookie 读取并验证签名 Cookie，使用密钥环中的任意密钥签名均可通过验证
+func (ctx *Context) SignedCookie(name string) (string, error) {
+	cookie, err := ctx.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	payload, encodedSignature, found := strings.Cut(cookie.Value, ".")
+	if !found {
+		return "", errorsx.ErrCookieTampered
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !ctx.Engine.KeyRing().Verify(cookieSignPurpose, []byte(name+"="+payload), signature) {
+		return "", errorsx.ErrCookieTampered
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errorsx.ErrCookieTampered
+	}
+	return decodeCookiePayload(data)
+}
+
+// SetEncryptedCookie 设置使用 AES-256-GCM 加密的 Cookie，客户端既无法读取也无法篡改
+func (ctx *Context) SetEncryptedCookie(name, value string, options ...CookieOptions) error {
+	cookie := newCookie(name, "", options)
+	sealed, err := ctx.Engine.KeyRing().Encrypt(cookieEncryptPurpose, encodeCookiePayload(value, cookieExpiry(cookie)), []byte(name))
+	if err != nil {
+		return err
+	}
+	cookie.Value = base64.RawURLEncoding.EncodeToString(sealed)
+	return ctx.writeCookie(cookie)
+}
+
+// EncryptedCookie 读取并解密 Cookie，使用密钥环中的任意密钥加密均可解密
+func (ctx *Context) EncryptedCookie(name string) (string, error) {
+	cookie, err := ctx.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return "", errorsx.ErrCookieTampered
+	}
+	payload, err := ctx.Engine.KeyRing().Decrypt(cookieEncryptPurpose, sealed, []byte(name))
+	if err != nil {
+		if err == errorsx.ErrKeyRingDecrypt {
+			return "", errorsx.ErrCookieTampered
+		}
+		return "", err
+	}
+	return decodeCookiePayload(payload)
+}
@@ -0,0 +1,133 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 13:52:37
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 13:52:37
+ * @FilePath: \gosh\cookie_test.go
+ * @Description: 测试签名与加密 Cookie 功能
+ */
+package gosh
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKeyOld = []byte("0123456789abcdef0123456789abcdef")
+	testKeyNew = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// issueCookie 执行处理器并返回响应中的 Cookie
+func issueCookie(t *testing.T, engine *Engine, handler HandlerFunc) *http.Cookie {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := &Context{Engine: engine, Request: req, ResponseWriter: httptest.NewRecorder()}
+	assert.NoError(t, handler(ctx))
+	cookies := (&http.Response{Header: ctx.ResponseWriter.Header()}).Cookies()
+	assert.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+// readCookie 构造携带 Cookie 的请求上下文
+func readCookie(engine *Engine, cookie *http.Cookie) *Context {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	return &Context{Engine: engine, Request: req, ResponseWriter: httptest.NewRecorder()}
+}
+
+// TestSetCookieWithOptions 测试 Cookie 选项与默认值
+func TestSetCookieWithOptions(t *testing.T) {
+	engine := NewEngine()
+	cookie := issueCookie(t, engine, func(ctx *Context) error {
+		return ctx.SetCookieWithOptions("theme", "dark mode", CookieOptions{SameSite: http.SameSiteNoneMode, HttpOnly: true})
+	})
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, http.SameSiteNoneMode, cookie.SameSite)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+
+	value, err := readCookie(engine, cookie).Cookie("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark mode", value)
+
+	recorder := httptest.NewRecorder()
+	ctx := &Context{Engine: engine, Request: httptest.NewRequest(http.MethodGet, "/", nil), ResponseWriter: recorder}
+	assert.Equal(t, errorsx.ErrCookieTooLarge, ctx.SetCookieWithOptions("big", strings.Repeat("x", maxCookieSize)))
+	assert.Empty(t, recorder.Header().Values("Set-Cookie"))
+}
+
+// TestSignedCookie 测试签名 Cookie 的签发、篡改与密钥轮换
+func TestSignedCookie(t *testing.T) {
+	engine := NewEngine(Config{SecretKeys: [][]byte{testKeyOld}})
+	cookie := issueCookie(t, engine, func(ctx *Context) error {
+		return ctx.SetSignedCookie("prefs", `{"lang":"zh"}`)
+	})
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+
+	value, err := readCookie(engine, cookie).SignedCookie("prefs")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"lang":"zh"}`, value)
+
+	// 轮换后旧密钥签发的 Cookie 仍然有效
+	assert.NoError(t, engine.KeyRing().Rotate(testKeyNew))
+	value, err = readCookie(engine, cookie).SignedCookie("prefs")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"lang":"zh"}`, value)
+
+	// 签名不能挪用到其它 Cookie
+	_, err = readCookie(engine, &http.Cookie{Name: "other", Value: cookie.Value}).SignedCookie("other")
+	assert.Equal(t, errorsx.ErrCookieTampered, err)
+
+	// 篡改载荷
+	_, signature, _ := strings.Cut(cookie.Value, ".")
+	forged := base64.RawURLEncoding.EncodeToString(encodeCookiePayload(`{"lang":"en"}`, 0))
+	tampered := &http.Cookie{Name: "prefs", Value: forged + "." + signature}
+	_, err = readCookie(engine, tampered).SignedCookie("prefs")
+	assert.Equal(t, errorsx.ErrCookieTampered, err)
+
+	// 旧密钥被移除后验证失败
+	assert.NoError(t, engine.KeyRing().Rotate([]byte("another-secret-key-32-bytes-long"), 2))
+	_, err = readCookie(engine, cookie).SignedCookie("prefs")
+	assert.Equal(t, errorsx.ErrCookieTampered, err)
+}
+
+// TestEncryptedCookie 测试加密 Cookie
+func TestEncryptedCookie(t *testing.T) {
+	engine := NewEngine(Config{SecretKeys: [][]byte{testKeyNew, testKeyOld}})
+	cookie := issueCookie(t, engine, func(ctx *Context) error {
+		return ctx.SetEncryptedCookie("uid", "10086", CookieOptions{MaxAge: 60})
+	})
+	assert.NotContains(t, cookie.Value, "10086")
+
+	value, err := readCookie(engine, cookie).EncryptedCookie("uid")
+	assert.NoError(t, err)
+	assert.Equal(t, "10086", value)
+
+	// 只保留旧密钥时无法解密由新密钥加密的 Cookie
+	other := NewEngine(Config{SecretKeys: [][]byte{testKeyOld}})
+	_, err = readCookie(other, cookie).EncryptedCookie("uid")
+	assert.Equal(t, errorsx.ErrCookieTampered, err)
+
+	// 过期的 Cookie
+	expired := issueCookie(t, engine, func(ctx *Context) error {
+		return ctx.SetEncryptedCookie("uid", "10086", CookieOptions{Expires: time.Now().Add(-time.Second)})
+	})
+	_, err = readCookie(engine, expired).EncryptedCookie("uid")
+	assert.Equal(t, errorsx.ErrCookieExpired, err)
+}
+
+// TestKeyRingConfig 测试密钥环配置校验
+func TestKeyRingConfig(t *testing.T) {
+	engine := NewEngine()
+	ctx := &Context{Engine: engine, Request: httptest.NewRequest(http.MethodGet, "/", nil), ResponseWriter: httptest.NewRecorder()}
+	assert.Equal(t, errorsx.ErrKeyRingEmpty, ctx.SetSignedCookie("a", "b"))
+	assert.Equal(t, errorsx.ErrSecretKeyTooShort, engine.KeyRing().Rotate([]byte("short")))
+	assert.Panics(t, func() { NewEngine(Config{SecretKeys: [][]byte{[]byte("short")}}) })
+}
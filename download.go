@@ -0,0 +1,218 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 17:45:33
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 17:45:33
+ * @FilePath: \gosh\download.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// zipCopyBufferSize 打包时每次复制的字节数，每复制一块检查一次客户端是否断开
+const zipCopyBufferSize = 32 * 1024
+
+// ZipEntry 打包下载的条目
+type ZipEntry struct {
+	Name    string    // 压缩包内的路径，为空时使用 Path 的文件名
+	Path    string    // 本地文件或目录，目录会被递归打包
+	Content io.Reader // Path 为空时使用的内容，适合打包动态生成的数据
+	ModTime time.Time // Content 的修改时间，为空时使用当前时间
+}
+
+// Attachment 以附件形式下载文件，filename 为空时使用文件本身的名称
+// 非 ASCII 文件名按 RFC 6266 与 RFC 5987 编码，同时提供 ASCII 兼容名称
+func (ctx *Context) Attachment(filePath, filename string) error {
+	if filename == "" {
+		filename = filepath.Base(filePath)
+	}
+	ctx.SetHeader(constants.HeaderContentDispositionKey, contentDisposition("attachment", filename))
+	return ctx.ServeFile(filePath)
+}
+
+// StreamZip 边打包边发送 zip 压缩包，不生成临时文件
+// 客户端断开连接时停止打包并返回 ctx.Err()
+func (ctx *Context) StreamZip(filename string, entries []ZipEntry) error {
+	ctx.setContentType(constants.ContentTypeZip)
+	ctx.SetHeader(constants.HeaderContentDispositionKey, contentDisposition("attachment", filename))
+	ctx.Status = http.StatusOK
+	ctx.ResponseWriter.WriteHeader(http.StatusOK)
+
+	writer := zip.NewWriter(ctx.ResponseWriter)
+	buf := make([]byte, zipCopyBufferSize)
+	for _, entry := range entries {
+		if err := ctx.writeZipEntry(writer, entry, buf); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// writeZipEntry 写入一个条目，目录会被递归写入
+func (ctx *Context) writeZipEntry(writer *zip.Writer, entry ZipEntry, buf []byte) error {
+	if entry.Path == "" {
+		modTime := entry.ModTime
+		if modTime.IsZero() {
+			modTime = time.Now()
+		}
+		header := &zip.FileHeader{Name: zipEntryName(entry.Name), Method: zip.Deflate, Modified: modTime}
+		return ctx.copyZipFile(writer, header, entry.Content, buf)
+	}
+
+	root := filepath.Clean(entry.Path)
+	name := entry.Name
+	if name == "" {
+		name = filepath.Base(root)
+	}
+	return filepath.WalkDir(root, func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		entryName := zipEntryName(path.Join(name, filepath.ToSlash(rel)))
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// 保留空目录，跳过符号链接等特殊文件
+		if d.IsDir() {
+			_, err := writer.CreateHeader(&zip.FileHeader{Name: entryName + "/", Modified: info.ModTime()})
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = entryName
+		header.Method = zip.Deflate
+
+		file, err := os.Open(current)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return ctx.copyZipFile(writer, header, file, buf)
+	})
+}
+
+// copyZipFile 写入单个文件，每复制一块检查一次客户端是否断开
+func (ctx *Context) copyZipFile(writer *zip.Writer, header *zip.FileHeader, src io.Reader, buf []byte) error {
+	dst, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		return nil
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// zipEntryName 规范化压缩包内的路径，去掉开头的 / 与 ..，防止解压时写出目标目录
+func zipEntryName(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "file"
+	}
+	return name
+}
+
+// contentDisposition 生成 Content-Disposition 头
+// filename 参数只能包含 ASCII，非 ASCII 字符替换为 _，完整名称通过 filename* 以 UTF-8 百分号编码提供
+func contentDisposition(dispositionType, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+	fallback, isASCII := asciiFilename(filename)
+	value := dispositionType + `; filename="` + fallback + `"`
+	if !isASCII {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// asciiFilename 生成 ASCII 兼容的文件名，返回原文件名是否可以原样使用
+func asciiFilename(filename string) (string, bool) {
+	var builder strings.Builder
+	isASCII := true
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			builder.WriteByte('_')
+			isASCII = false
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String(), isASCII
+}
+
+// encodeRFC5987 按 RFC 5987 的 attr-char 对字符串进行百分号编码
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isRFC5987AttrChar(c) {
+			builder.WriteByte(c)
+			continue
+		}
+		builder.WriteByte('%')
+		builder.WriteByte(hex[c>>4])
+		builder.WriteByte(hex[c&0x0f])
+	}
+	return builder.String()
+}
+
+// isRFC5987AttrChar 判断字节是否属于 attr-char，不需要编码
+func isRFC5987AttrChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,113 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 17:58:14
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 17:58:14
+ * @FilePath: \gosh\download_test.go
+ * @Description: 测试附件下载与流式打包功能
+ */
+package gosh
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestContentDisposition 测试 Content-Disposition 编码
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `attachment; filename="report.pdf"`, contentDisposition("attachment", "report.pdf"))
+	assert.Equal(t, `attachment; filename="a \"b\".txt"`, contentDisposition("attachment", `a "b".txt`))
+	assert.Equal(t,
+		`attachment; filename="__ 2026.pdf"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%202026.pdf`,
+		contentDisposition("attachment", "报告 2026.pdf"))
+
+	// 标准库可以解析出原始文件名
+	_, params, err := mime.ParseMediaType(contentDisposition("attachment", "季度报告(终版).xlsx"))
+	assert.NoError(t, err)
+	assert.Equal(t, "季度报告(终版).xlsx", params["filename"])
+}
+
+// TestAttachment 测试附件下载
+func TestAttachment(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("a,b\n1,2\n"), 0o644))
+
+	engine := NewEngine()
+	engine.GET("/download", func(ctx *Context) error {
+		return ctx.Attachment(path, "导出数据.csv")
+	})
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/download", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "a,b\n1,2\n", recorder.Body.String())
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), "filename*=UTF-8''%E5%AF%BC%E5%87%BA%E6%95%B0%E6%8D%AE.csv")
+}
+
+// TestStreamZip 测试打包文件、目录与动态内容
+func TestStreamZip(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "docs", "empty"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "a.txt"), []byte("aaa"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "readme.md"), []byte("# readme"), 0o644))
+
+	engine := NewEngine()
+	engine.GET("/zip", func(ctx *Context) error {
+		return ctx.StreamZip("归档.zip", []ZipEntry{
+			{Path: filepath.Join(dir, "readme.md")},
+			{Path: filepath.Join(dir, "docs"), Name: "文档"},
+			{Name: "../../generated.txt", Content: strings.NewReader("generated")},
+		})
+	})
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/zip", nil))
+	assert.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), "filename*=UTF-8''")
+
+	reader, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	assert.NoError(t, err)
+	contents := make(map[string]string)
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+		rc, err := file.Open()
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[file.Name] = string(content)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"generated.txt", "readme.md", "文档/", "文档/a.txt", "文档/empty/"}, names)
+	assert.Equal(t, "aaa", contents["文档/a.txt"])
+	assert.Equal(t, "generated", contents["generated.txt"])
+}
+
+// TestStreamZipCanceled 测试客户端断开连接时停止打包
+func TestStreamZipCanceled(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var zipErr error
+	engine := NewEngine()
+	engine.GET("/zip", func(ctx *Context) error {
+		zipErr = ctx.StreamZip("a.zip", []ZipEntry{{Name: "a.txt", Content: strings.NewReader("a")}})
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/zip", nil).WithContext(reqCtx)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	assert.ErrorIs(t, zipErr, context.Canceled)
+}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 08:59:07
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-17 13:26:00
+ * @LastEditTime: 2026-10-19 06:43:20
  * @FilePath: \gosh\engine.go
  * @Description:
  *
@@ -11,9 +11,10 @@
 package gosh
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"reflect"
@@ -22,8 +23,8 @@ import (
 	"sync"
 
 	translator "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
 	goconfig "github.com/kamalyes/go-config"
-	"github.com/kamalyes/go-toolbox/pkg/convert"
 	"github.com/kamalyes/go-toolbox/pkg/mathx"
 	"github.com/kamalyes/go-toolbox/pkg/random"
 	"github.com/kamalyes/gosh/constants"
@@ -33,12 +34,14 @@ import (
 // 常量定义
 const (
 	defaultMaxMultipartMemory = 32 << 20 // 默认32 MB
+	defaultLanguageQueryKey   = "lang"   // 默认指定语言的查询参数
 )
 
 // Config 引擎参数配置
 type Config struct {
 	MaxMultipartMemory     int64                  // 允许的请求Body大小(默认32 << 20 = 32MB)
 	Recovery               bool                   // 自动恢复panic，防止进程退出
+	RecoveryHandler        RecoveryHandlerFunc    // 自定义 panic 恢复处理器，设置后自动开启 Recovery
 	HandleMethodNotAllowed bool                   // 是否处理 405 错误（可以减少路由匹配时间），以 404 错误返回
 	BeforeHandler          CallbackHandler        // 前置回调处理器，总是会在其它处理器执行之前执行
 	ErrorHandler           CallbackHandler        // 错误回调处理器
@@ -46,8 +49,22 @@ type Config struct {
 	AppBanner              *BannerConfig          // Banner配置
 	AppName                string                 // 应用名称
 	Zap                    *Logger                // 日志
-	Trans                  translator.Translator  // Trans 全局validate翻译器
+	Trans                  translator.Translator  // 校验器翻译，设置后所有请求都使用，为空时按请求的语言选择内置翻译
+	Validator              *validator.Validate    // 校验器，为空时创建默认实例，首次使用时注册内置翻译
+	LanguageQueryKey       string                 // 指定语言的查询参数(默认lang)，优先于 Accept-Language；为 LanguageQueryDisabled 时关闭
 	KmSingleConfig         *goconfig.SingleConfig // 私有配置
+	Hub                    *HubConfig             // 消息中心配置
+	TrustedProxies         []string               // 可信代理列表(CIDR或IP)，为空时不信任任何转发请求头
+	TrustedHeaders         []string               // 读取客户端 IP 的请求头，按顺序尝试(默认X-Forwarded-For、X-Real-IP)
+	MaxBodySize            int64                  // 全局请求体大小限制，超过时返回 413，为 0 表示不限制
+	CacheRequestBody       bool                   // 是否缓存请求体，开启后请求体可以重复读取
+	BodySpillThreshold     int64                  // 缓存请求体时写入临时文件的阈值(默认4MB)
+	SecretKeys             [][]byte               // 签名与加密使用的密钥，按从新到旧排列，第一个为当前密钥
+	ProblemDetails         bool                   // 错误响应是否使用 RFC 7807 application/problem+json 格式
+	ProblemTypeBase        string                 // problem+json 中 type 的前缀，设置后 type 为前缀加业务状态码，为空时为 about:blank
+	ErrorPage              *ErrorPageConfig       // 浏览器访问时的 HTML 错误页面，为空时使用内置页面
+	Envelope               *EnvelopeConfig        // JSON 响应信封，为空时输出 code、message、data 与 request_id
+	Pagination             *PaginationConfig      // 分页参数配置，为空时使用 page/size、cursor/limit，每页默认 20 条、最多 100 条
 }
 
 // HandlerFunc 路由处理器函数类型
@@ -68,6 +85,19 @@ type Engine struct {
 	contextPool sync.Pool    // 上下文池
 	trees       methodTrees  // 路由树
 	routes      []*RouteInfo // 存储路由，使用 RouteInfo 结构体
+	hub         *Hub         // 消息中心
+	hubMu       sync.Mutex   // 保护 hub 字段
+	server      *http.Server // 由 Run 创建的 HTTP 服务
+	serverMu    sync.Mutex   // 保护 server 字段
+
+	trustedCIDRs []*net.IPNet // 解析后的可信代理网段
+	keyRing      *KeyRing     // 签名与加密使用的密钥环
+
+	routeMeta map[string]RouteMeta // 路由元数据，键为 "方法 路径"
+
+	validatorOnce sync.Once                        // 保证校验器只初始化一次
+	validate      *validator.Validate              // 注册了内置翻译的校验器
+	translators   map[string]translator.Translator // 小写语言 -> 校验器翻译
 }
 
 // NewEngine 新建引擎实例
@@ -87,6 +117,16 @@ func NewEngine(config ...Config) *Engine {
 		engine.Config = mergeDefaultConfig(engine.Config, config[0])
 	}
 
+	if err := engine.SetTrustedProxies(engine.Config.TrustedProxies); err != nil {
+		panic(err)
+	}
+
+	keyRing, err := NewKeyRing(engine.Config.SecretKeys...)
+	if err != nil {
+		panic(err)
+	}
+	engine.keyRing = keyRing
+
 	// 初始化上下文池
 	engine.contextPool.New = func() any {
 		return engine.allocateContext(engine.maxParams)
@@ -100,7 +140,30 @@ func (engine *Engine) Run(addr ...string) error {
 	resolveAddress := resolveAddress(addr)
 	engine.Config.AppBanner.Print()
 	log.Printf("Starting server at %s", resolveAddress)
-	return http.ListenAndServe(resolveAddress, engine)
+
+	server := &http.Server{Addr: resolveAddress, Handler: engine}
+	engine.serverMu.Lock()
+	engine.server = server
+	engine.serverMu.Unlock()
+	return server.ListenAndServe()
+}
+
+// Shutdown 优雅关闭服务：先通知消息中心的客户端并关闭消息中心，再等待进行中的请求结束
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.hubMu.Lock()
+	hub := engine.hub
+	engine.hubMu.Unlock()
+	if hub != nil {
+		hub.Close()
+	}
+
+	engine.serverMu.Lock()
+	server := engine.server
+	engine.serverMu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
 func resolveAddress(addr []string) string {
@@ -145,6 +208,7 @@ func (engine *Engine) allocateContext(maxParams int) *Context {
 		Engine:       engine,
 		params:       &v,
 		skippedNodes: &skippedNodes,
+		keysMu:       &sync.RWMutex{},
 	}
 }
 
@@ -226,6 +290,22 @@ func (engine *Engine) updateRoutes(method, path string, handler HandlersChain) {
 	engine.routes = append(engine.routes, routeInfo)
 }
 
+// setRouteMeta 记录路由元数据
+func (engine *Engine) setRouteMeta(method, path string, meta RouteMeta) {
+	for _, route := range engine.routes {
+		if route.Method == method && route.Path == path {
+			route.Meta = meta
+		}
+	}
+	if len(meta) == 0 {
+		return
+	}
+	if engine.routeMeta == nil {
+		engine.routeMeta = make(map[string]RouteMeta)
+	}
+	engine.routeMeta[method+" "+path] = meta
+}
+
 // updateMaxParamsAndSections 更新最大参数数量和路径段数量
 func (engine *Engine) updateMaxParamsAndSections(path string) {
 	if paramsCount := mathx.CountPathSegments(path, constants.PathSeparatorStr, constants.PathParamPrefixStr); paramsCount > engine.maxParams {
@@ -240,14 +320,14 @@ func (engine *Engine) updateMaxParamsAndSections(path string) {
 // ServeHTTP 处理HTTP请求
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ctx := engine.prepareContext(w, req) // 从池中获取并准备上下文
+	defer engine.releaseContext(ctx)     // 发生 panic 时同样把上下文放回池中
 
 	// 处理panic
 	if engine.Config.Recovery {
 		defer engine.recoverFromPanic(ctx)
 	}
 
-	engine.handleRequest(ctx)   // 处理请求
-	engine.contextPool.Put(ctx) // 将上下文放回池中
+	engine.handleRequest(ctx) // 处理请求
 }
 
 // prepareContext 准备上下文
@@ -256,20 +336,15 @@ func (engine *Engine) prepareContext(w http.ResponseWriter, req *http.Request) *
 	ctx.reset()                                // 重置上下文
 	// 初始化上下文
 	ctx.Request = req
-	ctx.ResponseWriter = w
+	ctx.writermem.reset(w)
+	ctx.ResponseWriter = &ctx.writermem
 	return ctx
 }
 
-// recoverFromPanic 处理panic
-func (engine *Engine) recoverFromPanic(ctx *Context) {
-	if err := recover(); err != nil {
-		// 创建自定义错误，附带 ErrorTypePrivate 表示这是一个私有错误
-		customErr := &errorsx.CustomError{
-			Err:       fmt.Errorf("%v", err),
-			ErrorType: errorsx.ErrorTypePrivate,
-		}
-		handleError(ctx, engine, customErr, http.StatusInternalServerError)
-	}
+// releaseContext 释放请求体缓存（如临时文件）并将上下文放回池中
+func (engine *Engine) releaseContext(ctx *Context) {
+	ctx.releaseBody()
+	engine.contextPool.Put(ctx)
 }
 
 // handleRequest 处理请求的核心逻辑
@@ -284,9 +359,7 @@ func (engine *Engine) handleRequest(ctx *Context) {
 		return
 	}
 	// OK 即正常逻辑
-	if node.handlers != nil {
-		engine.executeHandlers(node, ctx)
-	}
+	engine.executeHandlers(node, ctx)
 }
 
 // findNode 查找路由节点
@@ -297,6 +370,9 @@ func (engine *Engine) findNode(method, url string, ctx *Context) (nodeValue, boo
 		}
 		root := tree.root
 		node := root.getValue(url, ctx.params, ctx.skippedNodes) // 查找路由节点
+		if node.handlers == nil {
+			return nodeValue{}, false // 该方法下没有匹配的路由
+		}
 
 		if node.params != nil {
 			ctx.params = node.params
@@ -315,56 +391,36 @@ func (engine *Engine) executeHandlers(node nodeValue, ctx *Context) {
 	}
 
 	defer func() {
+		// 以实际写出的状态码为准，保证后置处理器和日志拿到真实结果
+		if writer := ctx.Writer(); writer.Written() {
+			ctx.Status = writer.Status()
+		}
 		if engine.Config.AfterHandler != nil {
 			engine.Config.AfterHandler(ctx)
 		}
 	}()
 
-	for _, handler := range node.handlers {
-		if ctx.broke {
-			break
-		}
-		// 执行处理器并检查错误
-		if err := handler(ctx); err != nil {
-			engine.handleError(ctx, err) // 将 err 传递给 handleError 方法
-			return
-		}
-	}
-}
-
-// handleError 封装错误处理
-func (engine *Engine) handleError(ctx *Context, err error) {
-	var customErr *errorsx.CustomError
-	// 尝试将 err 转换为 *errorsx.CustomError
-	if errors.As(err, &customErr) {
-		engine.processError(ctx, customErr, http.StatusInternalServerError)
+	// 应用全局请求体大小限制
+	if !ctx.applyBodyLimit(engine.Config.MaxBodySize) {
 		return
 	}
 
-	// 创建新的 CustomError
-	customErr = &errorsx.CustomError{
-		Err:       err,
-		ErrorType: errorsx.ErrorTypePrivate, // 指定错误类型
-	}
-	engine.processError(ctx, customErr, http.StatusInternalServerError)
+	// 执行处理器链，中间件可以调用 ctx.Next() 在后续处理器之后继续处理
+	ctx.handlers = node.handlers
+	ctx.index = -1
+	ctx.Next()
 }
 
-// processError 处理错误并执行错误处理器
-func (engine *Engine) processError(ctx *Context, err *errorsx.CustomError, status int) {
-	ctx.broke = true // 标记上下文为中断状态
-	ctx.Status = status
-	ctx.Error = err
-
-	if engine.Config.ErrorHandler != nil {
-		engine.Config.ErrorHandler(ctx) // 调用错误处理器
+// handleError 封装错误处理
+func (engine *Engine) handleError(ctx *Context, err error) {
+	// 读取请求体时超出大小限制
+	if isBodyTooLarge(err) {
+		ctx.respondBodyTooLarge(err)
 		return
 	}
-	ctx.ResponseWriter.WriteHeader(ctx.Status)
 
-	// 直接使用自定义错误的字符串表示
-	if _, errWrite := ctx.ResponseWriter.Write(convert.StringToSliceByte(err.Error())); errWrite != nil {
-		log.Println("写入响应时出错:", err)
-	}
+	// 错误链中没有 CustomError 时作为私有错误处理，状态码使用错误自身的设置
+	handleError(ctx, engine, errorsx.From(err), 0)
 }
 
 // handleNotFoundOrMethodNotAllowed 处理404或405错误
@@ -389,22 +445,61 @@ func (engine *Engine) isMethodAllowed(method, url string) bool {
 	return false
 }
 
-// handleError 处理错误并执行错误处理器
+// handleError 处理错误并执行错误处理器，status 为 0 时使用错误自身的 HTTP 状态码
 func handleError(ctx *Context, engine *Engine, err *errorsx.CustomError, status int) error {
+	if status == 0 {
+		status = err.HTTPStatus()
+	}
 	ctx.broke = true // 标记上下文为中断状态
 	ctx.Status = status
 	ctx.Error = err
+	ctx.AddError(err)
+
+	if ctx.isHijacked() {
+		ctx.logPrintln("连接已被劫持，无法写入错误响应:", err)
+		return nil
+	}
 
 	if engine.Config.ErrorHandler != nil {
 		engine.Config.ErrorHandler(ctx) // 调用错误处理器
 		return nil
 	}
-	ctx.ResponseWriter.WriteHeader(ctx.Status)
+	if ctx.Writer().Written() {
+		ctx.logPrintln("响应已经发送，无法写入错误响应:", err)
+		return nil
+	}
+	if !err.IsPublic() {
+		ctx.logPrintln("处理请求时发生错误:", err)
+	}
+	return renderError(ctx, err)
+}
 
-	// 直接使用自定义错误的字符串表示
-	if _, errWrite := ctx.ResponseWriter.Write(convert.StringToSliceByte(err.Error())); errWrite != nil {
-		log.Println("写入响应时出错:", err)
-		return errWrite
+// renderError 写出错误响应，格式根据 Accept 请求头协商
+// 公开错误返回自身的信息、业务状态码与附加信息，私有错误只返回状态码对应的通用信息
+func renderError(ctx *Context, err *errorsx.CustomError) error {
+	language := ctx.Language()
+	option := &ResponseOption{
+		HttpCode:  StatusCode(ctx.Status),
+		SceneCode: SceneCode(ctx.Status),
+		Message:   GetLocalizedStatusCodeText(language, StatusCode(ctx.Status)),
+		Language:  language,
+	}
+	if err.IsPublic() {
+		if err.SceneCode != 0 {
+			option.SceneCode = SceneCode(err.SceneCode)
+		}
+		if message := err.PublicMessage(); message != "" {
+			option.Message = message
+		}
+		// 预定义错误按客户端语言替换对外信息
+		if err.Key != "" {
+			if message, ok := DefaultCatalog.Message(language, I18nErrorKeyPrefix+err.Key); ok {
+				option.Message = message
+			}
+		}
+		if len(err.Details) > 0 {
+			option.Data = err.Details
+		}
 	}
-	return nil
+	return writeError(ctx, option)
 }
@@ -0,0 +1,219 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:10:41
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:13:47
+ * @FilePath: \gosh\envelope.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"net/http"
+	"time"
+)
+
+// 响应信封的默认字段名
+const (
+	EnvelopeCodeKey       = "code"
+	EnvelopeMessageKey    = "message"
+	EnvelopeDataKey       = "data"
+	EnvelopeMetaKey       = "meta"
+	EnvelopePaginationKey = "pagination"
+	EnvelopeRequestIDKey  = "request_id"
+)
+
+// EnvelopeOmit 作为字段名时不输出该字段
+const EnvelopeOmit = "-"
+
+// EnvelopeStatusMode 响应信封的 HTTP 状态码模式
+type EnvelopeStatusMode int
+
+const (
+	EnvelopeStatusDefault   EnvelopeStatusMode = iota // 使用 ResponseOption.HttpCode
+	EnvelopeStatusSceneCode                           // 业务状态码是合法的 HTTP 状态码(100-599)时作为 HTTP 状态码
+	EnvelopeStatusAlwaysOK                            // HTTP 状态码总是 200，结果只通过业务状态码区分
+)
+
+// EnvelopeConfig 响应信封配置，决定 SendJSONResponse 与 SendResponse 输出的字段
+// 字段名为空时使用默认值，为 EnvelopeOmit 时不输出；开启 Config.ProblemDetails 后错误响应不使用信封
+type EnvelopeConfig struct {
+	CodeKey         string                            // 业务状态码字段(默认code)
+	MessageKey      string                            // 消息字段(默认message)
+	DataKey         string                            // 数据字段(默认data)
+	MetaKey         string                            // 附加信息字段(默认meta)，没有附加信息时不输出
+	PaginationKey   string                            // 分页信息字段(默认pagination)，没有分页信息时不输出
+	RequestIDKey    string                            // 请求 ID 字段(默认request_id)，没有请求 ID 时不输出
+	TraceIDKey      string                            // 链路 ID 字段，为空时不输出
+	TimestampKey    string                            // 时间戳字段，为空时不输出
+	TimestampFormat string                            // 时间戳格式，为空时输出 Unix 毫秒数
+	StatusMode      EnvelopeStatusMode                // HTTP 状态码模式
+	Extra           func(ctx *Context) map[string]any // 附加的字段，不会覆盖上面的字段
+}
+
+// defaultEnvelope 没有配置时使用的响应信封
+var defaultEnvelope = &EnvelopeConfig{}
+
+// envelopeConfig 返回引擎配置的响应信封
+func (ctx *Context) envelopeConfig() *EnvelopeConfig {
+	if ctx.Engine == nil || ctx.Engine.Config.Envelope == nil {
+		return defaultEnvelope
+	}
+	return ctx.Engine.Config.Envelope
+}
+
+// status 返回响应使用的 HTTP 状态码
+func (cfg *EnvelopeConfig) status(respOption *ResponseOption) int {
+	switch cfg.StatusMode {
+	case EnvelopeStatusSceneCode:
+		if code := int(respOption.SceneCode); code >= 100 && code <= 599 {
+			return code
+		}
+	case EnvelopeStatusAlwaysOK:
+		return http.StatusOK
+	}
+	return int(respOption.HttpCode)
+}
+
+// build 按配置构建响应信封，respOption 需要先经过 Merge 处理
+func (cfg *EnvelopeConfig) build(ctx *Context, respOption *ResponseOption) map[string]any {
+	body := make(map[string]any, 8)
+	if cfg.Extra != nil {
+		for key, value := range cfg.Extra(ctx) {
+			body[key] = value
+		}
+	}
+
+	setEnvelopeField(body, envelopeKey(cfg.CodeKey, EnvelopeCodeKey), respOption.SceneCode)
+	setEnvelopeField(body, envelopeKey(cfg.MessageKey, EnvelopeMessageKey), respOption.Message)
+	setEnvelopeField(body, envelopeKey(cfg.DataKey, EnvelopeDataKey), respOption.Data)
+	if len(respOption.Meta) > 0 {
+		setEnvelopeField(body, envelopeKey(cfg.MetaKey, EnvelopeMetaKey), respOption.Meta)
+	}
+	if respOption.Paging != nil {
+		setEnvelopeField(body, envelopeKey(cfg.PaginationKey, EnvelopePaginationKey), respOption.Paging)
+	}
+	// 使用 RequestID 中间件时附带请求 ID，便于根据响应排查日志
+	if ctx.requestID != "" {
+		setEnvelopeField(body, envelopeKey(cfg.RequestIDKey, EnvelopeRequestIDKey), ctx.requestID)
+	}
+	if cfg.TraceIDKey != "" {
+		if sc := ctx.Span().SpanContext(); sc.TraceID.IsValid() {
+			setEnvelopeField(body, cfg.TraceIDKey, sc.TraceID.String())
+		}
+	}
+	if cfg.TimestampKey != "" {
+		now := time.Now()
+		if cfg.TimestampFormat == "" {
+			setEnvelopeField(body, cfg.TimestampKey, now.UnixMilli())
+		} else {
+			setEnvelopeField(body, cfg.TimestampKey, now.Format(cfg.TimestampFormat))
+		}
+	}
+	return body
+}
+
+// envelopeKey 返回字段名，未配置时使用默认值
+func envelopeKey(key, defaultKey string) string {
+	if key == "" {
+		return defaultKey
+	}
+	return key
+}
+
+// setEnvelopeField 设置字段，字段名为 EnvelopeOmit 时忽略
+func setEnvelopeField(body map[string]any, key string, value any) {
+	if key != EnvelopeOmit {
+		body[key] = value
+	}
+}
+
+// Pagination 分页信息，页码分页使用 Page、Size 与 TotalPages，游标分页使用 Limit、Next 与 Prev
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`        // 当前页码，从 1 开始
+	Size       int    `json:"size,omitempty"`        // 每页数量
+	Total      *int64 `json:"total,omitempty"`       // 总数，未知时为 nil
+	TotalPages int    `json:"total_pages,omitempty"` // 总页数
+	Limit      int    `json:"limit,omitempty"`       // 游标分页的每页数量
+	Next       string `json:"next,omitempty"`        // 下一页的游标，没有下一页时为空
+	Prev       string `json:"prev,omitempty"`        // 上一页的游标，没有上一页时为空
+}
+
+// NewPagination 创建页码分页信息，根据总数计算总页数
+func NewPagination(page, size int, total int64) *Pagination {
+	p := &Pagination{Page: page, Size: size, Total: &total}
+	if size > 0 {
+		p.TotalPages = int((total + int64(size) - 1) / int64(size))
+	}
+	return p
+}
+
+// Response 泛型响应，按 EnvelopeConfig 输出；直接序列化时使用默认字段名，可以用于客户端解析
+type Response[T any] struct {
+	Code       SceneCode      `json:"code"`                 // 业务状态码
+	Message    string         `json:"message"`              // 消息，为空时根据业务状态码生成
+	Data       T              `json:"data"`                 // 数据
+	Meta       map[string]any `json:"meta,omitempty"`       // 附加信息
+	Pagination *Pagination    `json:"pagination,omitempty"` // 分页信息
+	Status     StatusCode     `json:"-"`                    // HTTP 状态码，为 0 时为 200
+}
+
+// NewResponse 创建成功响应
+func NewResponse[T any](data T) *Response[T] {
+	return &Response[T]{Code: Success, Data: data}
+}
+
+// WithCode 设置业务状态码
+func (r *Response[T]) WithCode(code SceneCode) *Response[T] {
+	r.Code = code
+	return r
+}
+
+// WithStatus 设置 HTTP 状态码
+func (r *Response[T]) WithStatus(status StatusCode) *Response[T] {
+	r.Status = status
+	return r
+}
+
+// WithMessage 设置消息
+func (r *Response[T]) WithMessage(message string) *Response[T] {
+	r.Message = message
+	return r
+}
+
+// WithMeta 追加附加信息
+func (r *Response[T]) WithMeta(key string, value any) *Response[T] {
+	if r.Meta == nil {
+		r.Meta = make(map[string]any, 1)
+	}
+	r.Meta[key] = value
+	return r
+}
+
+// WithPagination 设置分页信息
+func (r *Response[T]) WithPagination(pagination *Pagination) *Response[T] {
+	r.Pagination = pagination
+	return r
+}
+
+// Option 转换为 ResponseOption
+func (r *Response[T]) Option() *ResponseOption {
+	return &ResponseOption{
+		Data:      r.Data,
+		SceneCode: r.Code,
+		HttpCode:  r.Status,
+		Message:   r.Message,
+		Meta:      r.Meta,
+		Paging:    r.Pagination,
+	}
+}
+
+// SendResponse 按引擎配置的响应信封输出泛型响应
+func SendResponse[T any](ctx *Context, resp *Response[T]) error {
+	if resp == nil {
+		resp = &Response[T]{}
+	}
+	return SendJSONResponse(ctx, resp.Option())
+}
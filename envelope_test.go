@@ -0,0 +1,128 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:11:02
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:46:07
+ * @FilePath: \gosh\envelope_test.go
+ * @Description: 测试响应信封与泛型响应功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveJSON 发起请求并将响应体解析为 JSON 对象
+func serveJSON(t *testing.T, engine *Engine, req *http.Request) (*httptest.ResponseRecorder, map[string]any) {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return recorder, body
+}
+
+// TestDefaultEnvelope 测试默认信封与泛型响应的分页、附加信息
+func TestDefaultEnvelope(t *testing.T) {
+	engine := NewEngine(Config{})
+	engine.Use(RequestID())
+	engine.GET("/users", func(ctx *Context) error {
+		resp := NewResponse([]string{"alice", "bob"}).
+			WithMeta("version", "v1").
+			WithPagination(NewPagination(2, 10, 35))
+		return SendResponse(ctx, resp)
+	})
+	engine.GET("/created", func(ctx *Context) error {
+		return SendResponse(ctx, NewResponse(map[string]int{"id": 7}).WithStatus(StatusCreated).WithMessage("已创建"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(constants.TraceIdKey, "req-7")
+	recorder, _ := serveJSON(t, engine, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{
+		"code": 200,
+		"message": "Success",
+		"data": ["alice", "bob"],
+		"meta": {"version": "v1"},
+		"pagination": {"page": 2, "size": 10, "total": 35, "total_pages": 4},
+		"request_id": "req-7"
+	}`, recorder.Body.String())
+
+	// 直接反序列化为泛型响应
+	var resp Response[[]string]
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"alice", "bob"}, resp.Data)
+	assert.Equal(t, int64(35), *resp.Pagination.Total)
+
+	recorder, body := serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/created", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "已创建", body["message"])
+	assert.NotContains(t, body, "meta")
+	assert.NotContains(t, body, "pagination")
+}
+
+// TestCustomEnvelope 测试自定义字段名、链路 ID、时间戳与 HTTP 状态码模式
+func TestCustomEnvelope(t *testing.T) {
+	envelope := &EnvelopeConfig{
+		CodeKey:         "errcode",
+		MessageKey:      "msg",
+		DataKey:         "result",
+		RequestIDKey:    EnvelopeOmit,
+		TraceIDKey:      "trace_id",
+		TimestampKey:    "ts",
+		TimestampFormat: time.RFC3339,
+		StatusMode:      EnvelopeStatusAlwaysOK,
+		Extra: func(ctx *Context) map[string]any {
+			return map[string]any{"version": "v2", "errcode": "忽略"}
+		},
+	}
+	engine := NewEngine(Config{Envelope: envelope})
+	engine.Use(RequestID(), Tracing(TracingConfig{}))
+	engine.GET("/items", func(ctx *Context) error {
+		return SendResponse(ctx, NewResponse([]int{1, 2}))
+	})
+	engine.GET("/missing-item", func(ctx *Context) error {
+		return errorsx.ErrNotFound
+	})
+
+	recorder, body := serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, float64(Success), body["errcode"])
+	assert.Equal(t, "Success", body["msg"])
+	assert.Equal(t, []any{float64(1), float64(2)}, body["result"])
+	assert.Equal(t, "v2", body["version"])
+	assert.NotContains(t, body, "request_id")
+	assert.NotContains(t, body, "data")
+	assert.Len(t, body["trace_id"], 32)
+	_, err := time.Parse(time.RFC3339, body["ts"].(string))
+	assert.NoError(t, err)
+
+	// 错误只通过业务状态码区分
+	recorder, body = serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/missing-item", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, float64(http.StatusNotFound), body["errcode"])
+	assert.Equal(t, "未找到请求的资源", body["msg"])
+
+	envelope.StatusMode = EnvelopeStatusSceneCode
+	envelope.TimestampFormat = ""
+	engine.GET("/limited", func(ctx *Context) error {
+		return SendJSONResponse(ctx, &ResponseOption{SceneCode: http.StatusTooManyRequests})
+	})
+	engine.GET("/invalid", func(ctx *Context) error {
+		return SendJSONResponse(ctx, &ResponseOption{SceneCode: ValidateError, HttpCode: StatusBadRequest})
+	})
+
+	recorder, body = serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.IsType(t, float64(0), body["ts"])
+	recorder, _ = serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/invalid", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
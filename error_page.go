@@ -0,0 +1,191 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 21:34:16
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:42:05
+ * @FilePath: \gosh\error_page.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// defaultErrorPage 内置的 HTML 错误页面
+var defaultErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Title}}</title>
+<style>body{font-family:sans-serif;margin:10vh auto;max-width:40em;color:#333}h1{font-weight:normal}small{color:#999}</style>
+</head>
+<body>
+<h1>{{.Status}} {{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .RequestID}}<small>Request ID: {{.RequestID}}</small>{{end}}
+</body>
+</html>
+`))
+
+// errorFormats 默认错误处理支持的响应格式，客户端没有偏好时优先使用 JSON
+var errorFormats = []string{constants.MIMEJSON, constants.MIMEProblemJSON, constants.MIMEHTML, constants.MIMEPlain}
+
+// ErrorPageConfig 浏览器访问时的 HTML 错误页面配置
+type ErrorPageConfig struct {
+	Template *template.Template         // 默认错误页面，为空时使用内置页面
+	Status   map[int]*template.Template // 按 HTTP 状态码覆盖的错误页面
+}
+
+// ErrorPageData 渲染错误页面时传入模板的数据
+type ErrorPageData struct {
+	Status    int       // HTTP 状态码
+	Title     string    // 状态码对应的标准文本，例如 Not Found
+	Message   string    // 错误信息，私有错误为状态码对应的通用信息
+	SceneCode SceneCode // 业务状态码
+	Path      string    // 请求路径
+	RequestID string    // 请求 ID，没有使用 RequestID 中间件时为空
+	TraceID   string    // 链路 ID，没有使用 Tracing 中间件时为空
+}
+
+// pageFor 返回状态码对应的错误页面
+func (c *ErrorPageConfig) pageFor(status int) *template.Template {
+	if c == nil {
+		return defaultErrorPage
+	}
+	if tmpl := c.Status[status]; tmpl != nil {
+		return tmpl
+	}
+	if c.Template != nil {
+		return c.Template
+	}
+	return defaultErrorPage
+}
+
+// writeError 根据 Accept 请求头选择错误响应格式：
+// API 客户端(JSON 或未指定偏好)返回 JSON 或 problem+json(取决于 Config.ProblemDetails)，
+// 明确要求 problem+json 时返回问题详情，浏览器返回 HTML 错误页面，其他情况返回纯文本
+func writeError(ctx *Context, respOption *ResponseOption) error {
+	if respOption.Language == "" {
+		respOption.Language = ctx.Language()
+	}
+	switch ctx.NegotiateFormat(errorFormats...) {
+	case constants.MIMEJSON:
+		return SendErrorResponse(ctx, respOption)
+	case constants.MIMEProblemJSON:
+		// 客户端只接受 problem+json 时，即使没有开启 Config.ProblemDetails 也返回问题详情
+		return ctx.WriteProblem(newProblemDetails(ctx, respOption.Merge()))
+	case constants.MIMEHTML:
+		return writeErrorPage(ctx, respOption.Merge())
+	default:
+		respOption.Merge()
+		return ctx.WriteString(int(respOption.HttpCode), respOption.Message)
+	}
+}
+
+// writeErrorPage 渲染 HTML 错误页面，模板执行失败时退回纯文本
+func writeErrorPage(ctx *Context, respOption *ResponseOption) error {
+	status := int(respOption.HttpCode)
+	data := ErrorPageData{
+		Status:    status,
+		Title:     http.StatusText(status),
+		Message:   respOption.Message,
+		SceneCode: respOption.SceneCode,
+		Path:      ctx.Request.URL.Path,
+		RequestID: ctx.requestID,
+	}
+	if sc := ctx.Span().SpanContext(); sc.TraceID.IsValid() {
+		data.TraceID = sc.TraceID.String()
+	}
+
+	var buf bytes.Buffer
+	if err := ctx.Engine.Config.ErrorPage.pageFor(status).Execute(&buf, data); err != nil {
+		ctx.logPrintln("渲染错误页面失败:", err)
+		return ctx.WriteString(status, respOption.Message)
+	}
+	ctx.Status = status
+	ctx.setContentType(constants.ContentTypeHtmlUTF8)
+	ctx.ResponseWriter.WriteHeader(status)
+	_, err := ctx.ResponseWriter.Write(buf.Bytes())
+	return err
+}
+
+// NegotiateFormat 根据 Accept 请求头从 offered 中选择客户端最能接受的媒体类型
+// 比较质量值(q)，相同时按 offered 的顺序；没有 Accept 请求头时返回第一个，都不可接受时返回空字符串
+func (ctx *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accept := ctx.Header(constants.HeaderAcceptKey)
+	if strings.TrimSpace(accept) == "" {
+		return offered[0]
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+	for _, mediaType := range offered {
+		if q := acceptQuality(ranges, mediaType); q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+	return best
+}
+
+// acceptRange Accept 请求头中的一个媒体范围
+type acceptRange struct {
+	mediaType string  // 媒体类型，可以是 type/subtype、type/* 或 */*
+	q         float64 // 质量值
+}
+
+// parseAccept 解析 Accept 请求头，忽略格式错误的成员
+func parseAccept(accept string) []acceptRange {
+	parts := strings.Split(accept, ",")
+	ranges := make([]acceptRange, 0, len(parts))
+	for _, part := range parts {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if !strings.Contains(mediaType, "/") {
+			continue
+		}
+		r := acceptRange{mediaType: mediaType, q: 1}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// acceptQuality 返回媒体类型的质量值，使用最具体的匹配范围：type/subtype 优先于 type/*，再优先于 */*
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		level := -1
+		switch {
+		case r.mediaType == mediaType:
+			level = 2
+		case r.mediaType == mainType+"/*":
+			level = 1
+		case r.mediaType == "*/*":
+			level = 0
+		}
+		if level > specificity {
+			q, specificity = r.q, level
+		}
+	}
+	return q
+}
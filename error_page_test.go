@@ -0,0 +1,121 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 21:52:08
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:42:05
+ * @FilePath: \gosh\error_page_test.go
+ * @Description: 测试错误页面与内容协商功能
+ */
+package gosh
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+// requestWithAccept 使用指定的 Accept 请求头发起请求
+func requestWithAccept(engine *Engine, method, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if accept != "" {
+		req.Header.Set(constants.HeaderAcceptKey, accept)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// TestNegotiateFormat 测试 Accept 请求头的质量值与匹配优先级
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{constants.MIMEJSON, constants.MIMEHTML, constants.MIMEPlain}
+	cases := map[string]string{
+		"":                                  constants.MIMEJSON,
+		"*/*":                               constants.MIMEJSON,
+		browserAccept:                       constants.MIMEHTML,
+		"text/plain":                        constants.MIMEPlain,
+		"text/*;q=0.5, application/json":    constants.MIMEJSON,
+		"text/*, text/html;q=0":             constants.MIMEPlain,
+		"application/json;q=0.2, */*;q=0.5": constants.MIMEHTML,
+		"image/png":                         "",
+	}
+	for accept, expected := range cases {
+		ctx := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		if accept != "" {
+			ctx.Request.Header.Set(constants.HeaderAcceptKey, accept)
+		}
+		assert.Equal(t, expected, ctx.NegotiateFormat(offered...), accept)
+	}
+}
+
+// TestErrorNegotiation 测试默认错误处理按 Accept 选择 JSON、HTML 或纯文本
+func TestErrorNegotiation(t *testing.T) {
+	engine := NewEngine(Config{HandleMethodNotAllowed: true})
+	engine.GET("/users", func(ctx *Context) error {
+		return errorsx.New(http.StatusBadRequest, "<script>alert(1)</script>")
+	})
+
+	// 未注册的路由返回 404
+	recorder := requestWithAccept(engine, http.MethodGet, "/missing", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, constants.ContentTypeJSON, recorder.Header().Get(constants.HeaderContentTypeKey))
+	assert.JSONEq(t, `{"code":404,"data":null,"message":"未找到请求的资源"}`, recorder.Body.String())
+
+	recorder = requestWithAccept(engine, http.MethodGet, "/missing", browserAccept)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, constants.ContentTypeHtmlUTF8, recorder.Header().Get(constants.HeaderContentTypeKey))
+	assert.Contains(t, recorder.Body.String(), "<h1>404 Not Found</h1>")
+	assert.Contains(t, recorder.Body.String(), "未找到请求的资源")
+
+	// 只接受 problem+json 的客户端即使没有开启 ProblemDetails 也得到问题详情
+	recorder = requestWithAccept(engine, http.MethodGet, "/missing", constants.MIMEProblemJSON)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Contains(t, recorder.Header().Get(constants.HeaderContentTypeKey), constants.MIMEProblemJSON)
+	assert.Contains(t, recorder.Body.String(), `"status":404`)
+
+	recorder = requestWithAccept(engine, http.MethodPost, "/users", "text/plain")
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, constants.ContentTypePlain, recorder.Header().Get(constants.HeaderContentTypeKey))
+	assert.Equal(t, "请求的方法不被允许", recorder.Body.String())
+
+	recorder = requestWithAccept(engine, http.MethodGet, "/users", "image/png")
+	assert.Equal(t, constants.ContentTypePlain, recorder.Header().Get(constants.HeaderContentTypeKey))
+
+	// 错误信息在 HTML 中被转义
+	recorder = requestWithAccept(engine, http.MethodGet, "/users", browserAccept)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "<script>")
+	assert.Contains(t, recorder.Body.String(), "&lt;script&gt;")
+}
+
+// TestErrorPageTemplates 测试自定义错误页面与按状态码覆盖
+func TestErrorPageTemplates(t *testing.T) {
+	engine := NewEngine(Config{ErrorPage: &ErrorPageConfig{
+		Template: template.Must(template.New("default").Parse(`{{.Status}}|{{.Message}}|{{.RequestID}}`)),
+		Status: map[int]*template.Template{
+			http.StatusNotFound: template.Must(template.New("404").Parse(`页面 {{.Path}} 不存在`)),
+		},
+	}})
+	engine.Use(RequestID())
+	engine.GET("/panic", func(ctx *Context) error {
+		return errorsx.ErrInternalServerError.Wrap(assert.AnError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(constants.HeaderAcceptKey, browserAccept)
+	req.Header.Set(constants.TraceIdKey, "req-9")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "500|内部服务器错误|req-9", recorder.Body.String())
+
+	recorder = requestWithAccept(engine, http.MethodGet, "/docs", browserAccept)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "页面 /docs 不存在", recorder.Body.String())
+}
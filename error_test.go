@@ -0,0 +1,78 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 20:48:21
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:46:07
+ * @FilePath: \gosh\error_test.go
+ * @Description: 测试错误模型与错误响应功能
+ */
+package gosh
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCustomErrorModel 测试错误的派生、包装与匹配
+func TestCustomErrorModel(t *testing.T) {
+	err := errorsx.ErrNotFound.Wrap(sql.ErrNoRows).WithDetail("id", 42)
+	assert.Equal(t, "未找到请求的资源: sql: no rows in result set", err.Error())
+	assert.True(t, errors.Is(err, errorsx.ErrNotFound))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.False(t, errors.Is(err, errorsx.ErrFileNotFound))
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus())
+	assert.Equal(t, "未找到请求的资源", err.PublicMessage())
+	assert.Nil(t, errorsx.ErrNotFound.Details) // 预定义错误不会被修改
+
+	wrapped := fmt.Errorf("查询订单: %w", errorsx.New(http.StatusConflict, "订单已存在"))
+	assert.Equal(t, http.StatusConflict, errorsx.From(wrapped).HTTPStatus())
+
+	private := errorsx.From(errors.New("dial tcp: connection refused"))
+	assert.False(t, private.IsPublic())
+	assert.Equal(t, "", private.PublicMessage())
+	assert.Equal(t, http.StatusInternalServerError, private.HTTPStatus())
+}
+
+// TestErrorResponse 测试公开错误返回自身的状态码与信息，私有错误被隐藏
+func TestErrorResponse(t *testing.T) {
+	var handlerErr error
+	engine := NewEngine()
+	engine.GET("/", func(ctx *Context) error {
+		return handlerErr
+	})
+	serve := func(err error) (*httptest.ResponseRecorder, map[string]any) {
+		handlerErr = err
+		return serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	recorder, resp := serve(errorsx.Wrap(sql.ErrConnDone, http.StatusConflict, "用户名已被占用").
+		WithSceneCode(CreateError).
+		WithDetail("field", "username"))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Equal(t, float64(CreateError), resp["code"])
+	assert.Equal(t, "用户名已被占用", resp["message"])
+	assert.Equal(t, map[string]any{"field": "username"}, resp["data"])
+	assert.NotContains(t, recorder.Body.String(), "sql:")
+
+	// 没有设置业务状态码时与 HTTP 状态码相同
+	recorder, resp = serve(errorsx.ErrAccessDenied)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, float64(http.StatusForbidden), resp["code"])
+	assert.Equal(t, "访问被拒绝", resp["message"])
+
+	recorder, resp = serve(errors.New("password=secret"))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, float64(Fail), resp["code"])
+	assert.NotContains(t, recorder.Body.String(), "secret")
+
+	recorder, _ = serve(errorsx.NewCustomError("缓存不可用", errorsx.ErrorTypePrivate).WithStatus(http.StatusBadGateway))
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "缓存不可用")
+}
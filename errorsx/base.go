@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 21:19:05
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-17 00:55:29
+ * @LastEditTime: 2026-10-19 06:13:47
  * @FilePath: \gosh\errorsx\base.go
  * @Description:
  *
@@ -13,19 +13,137 @@ package errorsx
 import (
 	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/kamalyes/gosh/constants"
 )
 
-// CustomError 是一个自定义错误类型，包含错误信息和错误类型
+// CustomError 是一个自定义错误类型，包含返回给客户端的信息、HTTP 状态码、业务状态码与内部原因
+// 公开错误(ErrorTypePublic)会把 Message、Status、SceneCode 与 Details 返回给客户端，
+// 私有错误只返回状态码对应的通用信息，Err 只用于日志与 errors.Is/As
 type CustomError struct {
-	Err       error
-	ErrorType ErrorType
+	Err       error          // 内部原因
+	ErrorType ErrorType      // 错误类型
+	Status    int            // HTTP 状态码，为 0 时按 500 处理
+	SceneCode int            // 业务状态码，为 0 时与 HTTP 状态码相同
+	Message   string         // 返回给客户端的信息，为空时公开错误使用 Err 的信息
+	Key       string         // 多语言消息键，按客户端语言在消息目录中查找 error.<Key> 替换 Message
+	Details   map[string]any // 附加信息，公开错误会放在响应的 data 中返回
+	origin    *CustomError   // 派生出当前错误的预定义错误，用于 errors.Is 判断
 }
 
-// Error 实现 error 接口
+// Error 实现 error 接口，包含对外信息与内部原因
 func (e *CustomError) Error() string {
-	return fmt.Sprintf("错误: %v, 类型: %d", e.Err, e.ErrorType)
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "" || e.Message == e.Err.Error():
+		return e.Err.Error()
+	default:
+		return e.Message + ": " + e.Err.Error()
+	}
+}
+
+// Unwrap 返回内部原因，支持 errors.Is/As 继续向下匹配
+func (e *CustomError) Unwrap() error {
+	return e.Err
+}
+
+// Is 通过 WithXxx 与 Wrap 派生出的错误与原错误相等，例如 errors.Is(ErrNotFound.Wrap(err), ErrNotFound)
+func (e *CustomError) Is(target error) bool {
+	t, ok := target.(*CustomError)
+	return ok && t.root() == e.root()
+}
+
+// IsPublic 返回错误信息是否可以返回给客户端
+func (e *CustomError) IsPublic() bool {
+	return e.ErrorType&ErrorTypePublic != 0
+}
+
+// HTTPStatus 返回 HTTP 状态码，没有设置时为 500
+func (e *CustomError) HTTPStatus() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// PublicMessage 返回可以展示给客户端的信息，私有错误返回空字符串
+func (e *CustomError) PublicMessage() string {
+	if !e.IsPublic() {
+		return ""
+	}
+	if e.Message == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+// WithStatus 返回设置了 HTTP 状态码的副本
+func (e *CustomError) WithStatus(status int) *CustomError {
+	cp := e.clone()
+	cp.Status = status
+	return cp
+}
+
+// WithSceneCode 返回设置了业务状态码的副本
+func (e *CustomError) WithSceneCode(code int) *CustomError {
+	cp := e.clone()
+	cp.SceneCode = code
+	return cp
+}
+
+// WithMessage 返回设置了对外信息的副本，自定义信息不再使用原来的多语言消息键
+func (e *CustomError) WithMessage(message string) *CustomError {
+	cp := e.clone()
+	cp.Message = message
+	cp.Key = ""
+	return cp
+}
+
+// WithKey 返回设置了多语言消息键的副本
+func (e *CustomError) WithKey(key string) *CustomError {
+	cp := e.clone()
+	cp.Key = key
+	return cp
+}
+
+// WithDetail 返回追加了附加信息的副本
+func (e *CustomError) WithDetail(key string, value any) *CustomError {
+	cp := e.clone()
+	if cp.Details == nil {
+		cp.Details = make(map[string]any, 1)
+	}
+	cp.Details[key] = value
+	return cp
+}
+
+// Wrap 返回以 cause 为内部原因的副本，对外信息保持不变
+func (e *CustomError) Wrap(cause error) *CustomError {
+	cp := e.clone()
+	cp.Err = cause
+	return cp
+}
+
+// clone 复制错误，预定义错误是共享的，修改前必须复制
+func (e *CustomError) clone() *CustomError {
+	cp := *e
+	cp.origin = e.root()
+	if e.Details != nil {
+		cp.Details = make(map[string]any, len(e.Details))
+		for key, value := range e.Details {
+			cp.Details[key] = value
+		}
+	}
+	return &cp
+}
+
+// root 返回最初的错误
+func (e *CustomError) root() *CustomError {
+	if e.origin != nil {
+		return e.origin
+	}
+	return e
 }
 
 // NewCustomError 创建一个新的 CustomError 实例
@@ -33,21 +151,114 @@ func NewCustomError(message string, errorType ErrorType) *CustomError {
 	return &CustomError{
 		Err:       errors.New(message),
 		ErrorType: errorType,
+		Message:   message,
 	}
 }
 
+// New 创建带有 HTTP 状态码的公开错误，message 会返回给客户端
+func New(status int, message string) *CustomError {
+	return NewCustomError(message, ErrorTypePublic).WithStatus(status)
+}
+
+// Wrap 创建以 err 为内部原因的公开错误，客户端只能看到 message
+func Wrap(err error, status int, message string) *CustomError {
+	return &CustomError{
+		Err:       err,
+		ErrorType: ErrorTypePublic,
+		Status:    status,
+		Message:   message,
+	}
+}
+
+// From 把任意错误转换为 CustomError，错误链中没有 CustomError 时作为 500 私有错误处理
+func From(err error) *CustomError {
+	var customErr *CustomError
+	if errors.As(err, &customErr) {
+		return customErr
+	}
+	return &CustomError{Err: err, ErrorType: ErrorTypePrivate}
+}
+
 // 常用错误
 var (
 	ErrPathMustStartWithSlash    = NewCustomError(fmt.Sprintf("路径必须以%v开头", constants.PathSeparator), ErrorTypePublic)
 	ErrMethodCannotBeEmpty       = NewCustomError("方法不能为空", ErrorTypePublic)
 	ErrMustHaveAtLeastOneHandler = NewCustomError("必须有至少一个处理器", ErrorTypePublic)
-	ErrWriteResponseFailed       = NewCustomError("写入响应时出错", ErrorTypePublic)
-	ErrNotFound                  = NewCustomError("未找到请求的资源", ErrorTypePublic)
-	ErrMethodNotAllowed          = NewCustomError("请求的方法不被允许", ErrorTypePublic)
+	ErrWriteResponseFailed       = NewCustomError("写入响应时出错", ErrorTypePublic).WithStatus(http.StatusInternalServerError).WithKey("write_response_failed")
+	ErrNotFound                  = NewCustomError("未找到请求的资源", ErrorTypePublic).WithStatus(http.StatusNotFound).WithKey("not_found")
+	ErrMethodNotAllowed          = NewCustomError("请求的方法不被允许", ErrorTypePublic).WithStatus(http.StatusMethodNotAllowed).WithKey("method_not_allowed")
 	ErrInvalidRedirectCode       = NewCustomError("状态码必须在300到308之间", ErrorTypePublic)
-	ErrNotMultipart              = NewCustomError("请求不是multipart格式", ErrorTypePublic)
-	ErrAccessDenied              = NewCustomError("访问被拒绝", ErrorTypePublic)
-	ErrFileNotFound              = NewCustomError("文件未找到", ErrorTypePublic)
-	ErrInternalServerError       = NewCustomError("内部服务器错误", ErrorTypePublic)
-	ErrDirectoryAccessForbidden  = NewCustomError("禁止访问目录", ErrorTypePublic)
+	ErrNotMultipart              = NewCustomError("请求不是multipart格式", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("not_multipart")
+	ErrAccessDenied              = NewCustomError("访问被拒绝", ErrorTypePublic).WithStatus(http.StatusForbidden).WithKey("access_denied")
+	ErrFileNotFound              = NewCustomError("文件未找到", ErrorTypePublic).WithStatus(http.StatusNotFound).WithKey("file_not_found")
+	ErrInternalServerError       = NewCustomError("内部服务器错误", ErrorTypePublic).WithStatus(http.StatusInternalServerError).WithKey("internal_server_error")
+	ErrDirectoryAccessForbidden  = NewCustomError("禁止访问目录", ErrorTypePublic).WithStatus(http.StatusForbidden).WithKey("directory_access_forbidden")
+	ErrHijackNotSupported        = NewCustomError("响应写入器不支持连接劫持", ErrorTypePrivate)
+	ErrBodyTooLarge              = NewCustomError("请求体超出大小限制", ErrorTypePublic).WithStatus(http.StatusRequestEntityTooLarge).WithKey("body_too_large")
+)
+
+// WebSocket 相关错误
+var (
+	ErrWebSocketBadHandshake   = NewCustomError("WebSocket 握手请求不合法", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("websocket_bad_handshake")
+	ErrWebSocketBadVersion     = NewCustomError("不支持的 WebSocket 协议版本", ErrorTypePublic).WithStatus(http.StatusUpgradeRequired).WithKey("websocket_bad_version")
+	ErrWebSocketOriginDenied   = NewCustomError("WebSocket 请求来源不被允许", ErrorTypePublic).WithStatus(http.StatusForbidden).WithKey("websocket_origin_denied")
+	ErrWebSocketClosed         = NewCustomError("WebSocket 连接已关闭", ErrorTypePrivate)
+	ErrWebSocketReadLimit      = NewCustomError("WebSocket 消息超出读取限制", ErrorTypePrivate)
+	ErrWebSocketProtocol       = NewCustomError("WebSocket 协议错误", ErrorTypePrivate)
+	ErrWebSocketInvalidUTF8    = NewCustomError("WebSocket 文本消息不是合法的 UTF-8", ErrorTypePrivate)
+	ErrWebSocketControlTooLong = NewCustomError("WebSocket 控制帧负载过长", ErrorTypePrivate)
+)
+
+// Hub 相关错误
+var (
+	ErrHubClosed       = NewCustomError("消息中心已关闭", ErrorTypePrivate)
+	ErrHubSlowConsumer = NewCustomError("订阅者消费过慢已被移除", ErrorTypePrivate)
+)
+
+// 密钥与 Cookie 相关错误
+var (
+	ErrSecretKeyTooShort = NewCustomError("密钥长度不能少于 16 字节", ErrorTypePrivate)
+	ErrKeyRingEmpty      = NewCustomError("密钥环中没有可用的密钥", ErrorTypePrivate)
+	ErrKeyRingDecrypt    = NewCustomError("数据解密失败", ErrorTypePrivate)
+	ErrCookieTampered    = NewCustomError("Cookie 校验失败或已被篡改", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("cookie_tampered")
+	ErrCookieExpired     = NewCustomError("Cookie 已过期", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("cookie_expired")
+	ErrCookieTooLarge    = NewCustomError("Cookie 超出 4096 字节限制", ErrorTypePrivate)
+)
+
+// 上传相关错误
+var (
+	ErrUploadFileTooLarge   = NewCustomError("上传文件超出大小限制", ErrorTypePublic).WithStatus(http.StatusRequestEntityTooLarge).WithKey("upload_file_too_large")
+	ErrUploadTooLarge       = NewCustomError("上传内容超出总大小限制", ErrorTypePublic).WithStatus(http.StatusRequestEntityTooLarge).WithKey("upload_too_large")
+	ErrUploadFieldTooLarge  = NewCustomError("表单字段超出大小限制", ErrorTypePublic).WithStatus(http.StatusRequestEntityTooLarge).WithKey("upload_field_too_large")
+	ErrUploadTooManyFiles   = NewCustomError("上传文件数量超出限制", ErrorTypePublic).WithStatus(http.StatusRequestEntityTooLarge).WithKey("upload_too_many_files")
+	ErrUploadTypeNotAllowed = NewCustomError("不允许上传该类型的文件", ErrorTypePublic).WithStatus(http.StatusUnsupportedMediaType).WithKey("upload_type_not_allowed")
+)
+
+// 断点续传相关错误
+var (
+	ErrTusStoreRequired   = NewCustomError("断点续传必须指定存储", ErrorTypePrivate)
+	ErrTusUploadNotFound  = NewCustomError("上传不存在", ErrorTypePublic).WithStatus(http.StatusNotFound).WithKey("tus_upload_not_found")
+	ErrTusInvalidMetadata = NewCustomError("Upload-Metadata 格式错误", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("tus_invalid_metadata")
+)
+
+// 签名链接相关错误
+var (
+	ErrSignedURLInvalid = NewCustomError("链接签名无效", ErrorTypePublic).WithStatus(http.StatusForbidden).WithKey("signed_url_invalid")
+	ErrSignedURLExpired = NewCustomError("链接已过期", ErrorTypePublic).WithStatus(http.StatusForbidden).WithKey("signed_url_expired")
+)
+
+// 超时相关错误
+var (
+	ErrHandlerTimeout = NewCustomError("处理请求超时", ErrorTypePublic).WithStatus(http.StatusServiceUnavailable).WithKey("handler_timeout")
+)
+
+// 链路追踪相关错误
+var (
+	ErrSpanExporterClosed = NewCustomError("链路导出器已关闭", ErrorTypePrivate)
+)
+
+// 分页相关错误
+var (
+	ErrInvalidPagination = NewCustomError("分页参数不合法", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("invalid_pagination")
+	ErrInvalidCursor     = NewCustomError("分页游标无效或已过期", ErrorTypePublic).WithStatus(http.StatusBadRequest).WithKey("invalid_cursor")
 )
@@ -0,0 +1,428 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 10:18:02
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:42:21
+ * @FilePath: \gosh\hub.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/random"
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 常量定义
+const (
+	defaultHubQueueSize         = 64               // 默认每个订阅者的消息队列长度
+	defaultHubHeartbeatInterval = 30 * time.Second // 默认 SSE 心跳间隔
+	HubShutdownEvent            = "shutdown"       // 关闭时通知客户端的事件名
+)
+
+// HubConfig 消息中心参数配置
+type HubConfig struct {
+	QueueSize         int                    // 每个订阅者的缓冲队列长度(默认64)
+	HeartbeatInterval time.Duration          // SSE 连接的心跳间隔(默认30s)，小于 0 表示关闭心跳
+	ShutdownMessage   string                 // 关闭时发送给客户端的说明
+	OnEvict           func(*Subscriber)      // 订阅者因消费过慢被移除时的回调
+	OnPublish         func(*HubMessage, int) // 消息发布后的回调，参数为消息和投递数量
+}
+
+// HubMessage 表示一条发布到主题的消息
+type HubMessage struct {
+	ID    string    // 消息 ID
+	Topic string    // 主题
+	Event string    // 事件名称（SSE 中对应 event 字段）
+	Data  []byte    // 消息内容
+	Time  time.Time // 发布时间
+}
+
+// Hub 进程内基于主题的发布订阅中心，可向 SSE 与 WebSocket 客户端广播消息
+type Hub struct {
+	mu          sync.RWMutex
+	config      HubConfig
+	topics      map[string]map[*Subscriber]struct{} // 主题 -> 订阅者集合
+	subscribers map[*Subscriber]struct{}            // 全部订阅者
+	closed      bool
+	sequence    uint64
+}
+
+// NewHub 创建消息中心
+func NewHub(config ...HubConfig) *Hub {
+	var cfg HubConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultHubQueueSize
+	}
+	if cfg.HeartbeatInterval == 0 {
+		cfg.HeartbeatInterval = defaultHubHeartbeatInterval
+	}
+	return &Hub{
+		config:      cfg,
+		topics:      make(map[string]map[*Subscriber]struct{}),
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Hub 返回引擎的消息中心，首次调用时按 Config.Hub 创建
+func (engine *Engine) Hub() *Hub {
+	engine.hubMu.Lock()
+	defer engine.hubMu.Unlock()
+	if engine.hub == nil {
+		var cfg []HubConfig
+		if engine.Config.Hub != nil {
+			cfg = append(cfg, *engine.Config.Hub)
+		}
+		engine.hub = NewHub(cfg...)
+	}
+	return engine.hub
+}
+
+// Subscriber 消息中心的订阅者，每个订阅者拥有独立的缓冲队列
+type Subscriber struct {
+	ID        string
+	hub       *Hub
+	queue     chan *HubMessage
+	topics    map[string]struct{} // 由 hub.mu 保护
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// Messages 返回订阅者的消息通道
+func (s *Subscriber) Messages() <-chan *HubMessage {
+	return s.queue
+}
+
+// Done 返回订阅结束时会被关闭的通道
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err 返回订阅结束的原因：主动取消为 nil，消费过慢为 ErrHubSlowConsumer，中心关闭为 ErrHubClosed
+func (s *Subscriber) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Topics 返回当前订阅的主题
+func (s *Subscriber) Topics() []string {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Subscribe 追加订阅主题
+func (s *Subscriber) Subscribe(topics ...string) error {
+	return s.hub.addTopics(s, topics)
+}
+
+// Unsubscribe 取消订阅主题
+func (s *Subscriber) Unsubscribe(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, topic := range topics {
+		s.hub.removeTopicLocked(s, topic)
+	}
+}
+
+// Close 取消全部订阅
+func (s *Subscriber) Close() {
+	s.hub.remove(s, nil)
+}
+
+// finish 结束订阅并记录原因
+func (s *Subscriber) finish(err error) {
+	s.closeOnce.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Subscribe 创建订阅者并订阅指定主题
+func (h *Hub) Subscribe(topics ...string) (*Subscriber, error) {
+	sub := &Subscriber{
+		ID:     random.FRandHexString(16),
+		hub:    h,
+		queue:  make(chan *HubMessage, h.config.QueueSize),
+		topics: make(map[string]struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := h.addTopics(sub, topics); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// addTopics 为订阅者登记主题
+func (h *Hub) addTopics(sub *Subscriber, topics []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return errorsx.ErrHubClosed
+	}
+	select {
+	case <-sub.done:
+		return sub.err
+	default:
+	}
+
+	h.subscribers[sub] = struct{}{}
+	for _, topic := range topics {
+		members, ok := h.topics[topic]
+		if !ok {
+			members = make(map[*Subscriber]struct{})
+			h.topics[topic] = members
+		}
+		members[sub] = struct{}{}
+		sub.topics[topic] = struct{}{}
+	}
+	return nil
+}
+
+// removeTopicLocked 在持有写锁的前提下移除订阅者的单个主题
+func (h *Hub) removeTopicLocked(sub *Subscriber, topic string) {
+	if members, ok := h.topics[topic]; ok {
+		delete(members, sub)
+		if len(members) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+	delete(sub.topics, topic)
+}
+
+// remove 移除订阅者并结束订阅
+func (h *Hub) remove(sub *Subscriber, reason error) {
+	h.mu.Lock()
+	for topic := range sub.topics {
+		h.removeTopicLocked(sub, topic)
+	}
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+	sub.finish(reason)
+}
+
+// Publish 向主题发布消息，data 为 []byte 或 string 时原样发送，其它类型序列化为 JSON
+// 返回成功投递的订阅者数量，队列已满的订阅者会被移除
+func (h *Hub) Publish(topic string, data any) (int, error) {
+	return h.PublishEvent(topic, "", data)
+}
+
+// PublishEvent 向主题发布带事件名称的消息
+func (h *Hub) PublishEvent(topic, event string, data any) (int, error) {
+	payload, err := encodeEventData(data)
+	if err != nil {
+		return 0, err
+	}
+	message := &HubMessage{
+		ID:    strconv.FormatUint(atomic.AddUint64(&h.sequence, 1), 10),
+		Topic: topic,
+		Event: event,
+		Data:  payload,
+		Time:  time.Now(),
+	}
+
+	var delivered int
+	var slow []*Subscriber
+
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return 0, errorsx.ErrHubClosed
+	}
+	for sub := range h.topics[topic] {
+		select {
+		case sub.queue <- message:
+			delivered++
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	// 队列已满说明订阅者消费过慢，将其移除以免拖慢其它订阅者
+	for _, sub := range slow {
+		h.remove(sub, errorsx.ErrHubSlowConsumer)
+		if h.config.OnEvict != nil {
+			h.config.OnEvict(sub)
+		}
+	}
+	if h.config.OnPublish != nil {
+		h.config.OnPublish(message, delivered)
+	}
+	return delivered, nil
+}
+
+// Presence 返回主题当前的订阅者数量
+func (h *Hub) Presence(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Topics 返回全部主题及其订阅者数量
+func (h *Hub) Topics() map[string]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result := make(map[string]int, len(h.topics))
+	for topic, members := range h.topics {
+		result[topic] = len(members)
+	}
+	return result
+}
+
+// SubscriberCount 返回订阅者总数
+func (h *Hub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Close 关闭消息中心，所有订阅者会以 ErrHubClosed 结束，
+// 由 ServeSSE/ServeWebSocket 托管的连接会在断开前收到关闭通知
+func (h *Hub) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	subscribers := make([]*Subscriber, 0, len(h.subscribers))
+	for sub := range h.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	h.topics = make(map[string]map[*Subscriber]struct{})
+	h.subscribers = make(map[*Subscriber]struct{})
+	h.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.finish(errorsx.ErrHubClosed)
+	}
+}
+
+// IsClosed 返回消息中心是否已关闭
+func (h *Hub) IsClosed() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.closed
+}
+
+// ServeSSE 以 SSE 方式将主题消息推送给当前请求，直到客户端断开或消息中心关闭
+func (h *Hub) ServeSSE(ctx *Context, topics ...string) error {
+	sub, err := h.Subscribe(topics...)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	ctx.SetupSSE()
+
+	var heartbeat <-chan time.Time
+	if h.config.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(h.config.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case message := <-sub.Messages():
+			if err := ctx.WriteServerSentEvent(&ServerSentEvent{ID: message.ID, Event: message.Event, Data: message.Data}); err != nil {
+				return err
+			}
+		case <-heartbeat:
+			if err := ctx.writeSSEComment("ping"); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		case <-sub.Done():
+			if errors.Is(sub.Err(), errorsx.ErrHubClosed) {
+				return ctx.WriteServerSentEvent(&ServerSentEvent{Event: HubShutdownEvent, Data: []byte(h.config.ShutdownMessage)})
+			}
+			return sub.Err()
+		}
+	}
+}
+
+// hubWebSocketFrame 推送给 WebSocket 客户端的消息格式
+type hubWebSocketFrame struct {
+	ID    string `json:"id"`              // 消息 ID
+	Topic string `json:"topic"`           // 主题
+	Event string `json:"event,omitempty"` // 事件名称
+	Data  string `json:"data"`            // 消息内容
+}
+
+// ServeWebSocket 将主题消息推送给 WebSocket 连接，直到连接断开或消息中心关闭
+// 每条消息编码为一个 JSON 文本帧：{"id", "topic", "event", "data"}，与 SSE 推送的字段相同
+// onMessage 不为 nil 时会收到客户端发送的数据消息
+func (h *Hub) ServeWebSocket(conn *WebSocketConn, onMessage func(WebSocketMessageType, []byte), topics ...string) error {
+	sub, err := h.Subscribe(topics...)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	// 读循环负责处理控制帧以及感知客户端断开
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			if onMessage != nil {
+				onMessage(messageType, data)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case message := <-sub.Messages():
+			frame, err := json.Marshal(hubWebSocketFrame{ID: message.ID, Topic: message.Topic, Event: message.Event, Data: string(message.Data)})
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(TextMessage, frame); err != nil {
+				return err
+			}
+		case err := <-readErr:
+			if IsCloseError(err) {
+				return nil
+			}
+			return err
+		case <-conn.Done():
+			return nil
+		case <-sub.Done():
+			if errors.Is(sub.Err(), errorsx.ErrHubClosed) {
+				return conn.WriteClose(CloseGoingAway, h.config.ShutdownMessage)
+			}
+			conn.WriteClose(ClosePolicyViolation, "slow consumer")
+			return sub.Err()
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 10:52:31
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:42:21
+ * @FilePath: \gosh\hub_test.go
+ * @Description: 测试 Hub 功能
+ */
+package gosh
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHubPublishSubscribe 测试发布订阅与在线人数
+func TestHubPublishSubscribe(t *testing.T) {
+	hub := NewHub()
+	sub1, err := hub.Subscribe("news", "sports")
+	assert.NoError(t, err)
+	sub2, err := hub.Subscribe("news")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, hub.Presence("news"))
+	assert.Equal(t, 1, hub.Presence("sports"))
+
+	delivered, err := hub.Publish("news", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, "hello", string((<-sub1.Messages()).Data))
+	assert.Equal(t, "hello", string((<-sub2.Messages()).Data))
+
+	delivered, _ = hub.Publish("sports", H{"score": 1})
+	assert.Equal(t, 1, delivered)
+	assert.JSONEq(t, `{"score":1}`, string((<-sub1.Messages()).Data))
+
+	sub2.Close()
+	assert.Equal(t, 1, hub.Presence("news"))
+	assert.NoError(t, sub2.Err())
+
+	sub1.Unsubscribe("sports")
+	assert.Equal(t, map[string]int{"news": 1}, hub.Topics())
+}
+
+// TestHubEvictSlowConsumer 测试移除消费过慢的订阅者
+func TestHubEvictSlowConsumer(t *testing.T) {
+	evicted := make(chan string, 1)
+	hub := NewHub(HubConfig{QueueSize: 1, OnEvict: func(sub *Subscriber) { evicted <- sub.ID }})
+	sub, _ := hub.Subscribe("topic")
+
+	hub.Publish("topic", "1")
+	delivered, _ := hub.Publish("topic", "2")
+	assert.Equal(t, 0, delivered)
+	assert.Equal(t, sub.ID, <-evicted)
+	assert.ErrorIs(t, sub.Err(), errorsx.ErrHubSlowConsumer)
+	assert.Equal(t, 0, hub.Presence("topic"))
+}
+
+// TestHubServeSSE 测试 SSE 推送与关闭通知
+func TestHubServeSSE(t *testing.T) {
+	engine := NewEngine(Config{Hub: &HubConfig{ShutdownMessage: "bye"}})
+	engine.GET("/events", func(ctx *Context) error {
+		return ctx.Engine.Hub().ServeSSE(ctx, ctx.QueryValue("topic"))
+	})
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?topic=orders")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	assert.Eventually(t, func() bool { return engine.Hub().Presence("orders") == 1 }, time.Second, 10*time.Millisecond)
+	engine.Hub().PublishEvent("orders", "created", "order-1")
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			assert.NoError(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "id: 1\nevent: created\ndata: order-1\n", readEvent())
+
+	assert.NoError(t, engine.Shutdown(context.Background()))
+	assert.Equal(t, "event: shutdown\ndata: bye\n", readEvent())
+}
+
+// TestHubShutdownConcurrent 测试请求首次创建消息中心时同时关闭引擎
+func TestHubShutdownConcurrent(t *testing.T) {
+	engine := NewEngine()
+	done := make(chan *Hub)
+	go func() { done <- engine.Hub() }()
+	assert.NoError(t, engine.Shutdown(context.Background()))
+	assert.NotNil(t, <-done)
+}
+
+// TestHubServeWebSocket 测试 WebSocket 推送与关闭通知
+func TestHubServeWebSocket(t *testing.T) {
+	engine := NewEngine()
+	engine.WebSocket("/ws", func(ctx *Context, conn *WebSocketConn) error {
+		return ctx.Engine.Hub().ServeWebSocket(conn, nil, "chat")
+	})
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	conn, _, err := DialWebSocket(context.Background(), server.URL+"/ws", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.Eventually(t, func() bool { return engine.Hub().Presence("chat") == 1 }, time.Second, 10*time.Millisecond)
+	engine.Hub().Publish("chat", "hi")
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","topic":"chat","data":"hi"}`, string(data))
+
+	engine.Hub().PublishEvent("chat", "joined", "kamalyes")
+	_, data, err = conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"2","topic":"chat","event":"joined","data":"kamalyes"}`, string(data))
+
+	engine.Hub().Close()
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseGoingAway))
+	assert.Eventually(t, func() bool { return engine.Hub().SubscriberCount() == 0 }, time.Second, 10*time.Millisecond)
+}
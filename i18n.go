@@ -0,0 +1,355 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 23:21:37
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:43:20
+ * @FilePath: \gosh\i18n.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 消息键前缀
+const (
+	I18nSceneKeyPrefix  = "scene."  // 业务状态码消息，例如 scene.1001
+	I18nStatusKeyPrefix = "status." // HTTP 状态码消息，例如 status.404
+	I18nErrorKeyPrefix  = "error."  // 预定义错误消息，例如 error.not_found，见 errorsx.CustomError.Key
+)
+
+// LanguageQueryDisabled 作为 Config.LanguageQueryKey 时不从查询参数选择语言，只使用 Accept-Language
+const LanguageQueryDisabled = "-"
+
+//go:embed locales/*.json
+var builtinLocales embed.FS
+
+// DefaultCatalog 默认消息目录，内置 en 与 zh-CN 两种语言，可以继续加载自定义消息
+// 默认语言为空，客户端没有发送 Accept-Language 或没有匹配的语言时保持原有消息
+var DefaultCatalog = newBuiltinCatalog()
+
+// Catalog 多语言消息目录，按语言与消息键存储消息，可以在多个 goroutine 中使用
+// 消息文件按语言划分，文件名(不含扩展名)即语言，例如 zh-CN.yaml；嵌套的键使用 . 连接
+type Catalog struct {
+	mu            sync.RWMutex
+	defaultLocale string                       // 默认语言
+	locales       map[string]string            // 小写语言 -> 原始语言名称
+	messages      map[string]map[string]string // 小写语言 -> 消息键 -> 消息
+}
+
+// NewCatalog 创建消息目录，defaultLocale 为找不到消息时的兜底语言，可以为空
+func NewCatalog(defaultLocale string) *Catalog {
+	return &Catalog{
+		defaultLocale: normalizeLocale(defaultLocale),
+		locales:       make(map[string]string),
+		messages:      make(map[string]map[string]string),
+	}
+}
+
+// newBuiltinCatalog 创建包含内置消息的目录
+func newBuiltinCatalog() *Catalog {
+	catalog := NewCatalog("")
+	if err := catalog.LoadFS(builtinLocales, "locales/*.json"); err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// SetDefaultLocale 设置兜底语言
+func (c *Catalog) SetDefaultLocale(locale string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultLocale = normalizeLocale(locale)
+}
+
+// DefaultLocale 返回兜底语言
+func (c *Catalog) DefaultLocale() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.defaultLocale
+}
+
+// Add 添加或覆盖某个语言的消息
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		return
+	}
+	key := strings.ToLower(locale)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[key] == nil {
+		c.messages[key] = make(map[string]string, len(messages))
+		c.locales[key] = locale
+	}
+	for k, v := range messages {
+		c.messages[key][k] = v
+	}
+}
+
+// Load 解析 JSON、YAML 或 TOML 格式的消息并添加到 locale，format 为 json、yaml、yml 或 toml
+func (c *Catalog) Load(locale, format string, data []byte) error {
+	var raw map[string]any
+	var err error
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "json":
+		err = json.Unmarshal(data, &raw)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &raw)
+	case "toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("不支持的消息文件格式: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("解析 %s 消息失败: %w", locale, err)
+	}
+
+	messages := make(map[string]string)
+	flattenMessages("", raw, messages)
+	c.Add(locale, messages)
+	return nil
+}
+
+// LoadFile 加载消息文件，语言取自文件名，例如 locales/zh-CN.toml
+func (c *Catalog) LoadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(filename)
+	return c.Load(strings.TrimSuffix(filepath.Base(filename), ext), ext, data)
+}
+
+// LoadFS 加载文件系统中与 pattern 匹配的消息文件，可以配合 embed.FS 使用
+func (c *Catalog) LoadFS(fsys fs.FS, pattern string) error {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, name := range matches {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		ext := path.Ext(name)
+		if err := c.Load(strings.TrimSuffix(path.Base(name), ext), ext, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Locales 返回已加载的语言，按名称排序
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locales := make([]string, 0, len(c.locales))
+	for _, locale := range c.locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Message 查找消息，依次尝试 locale、去掉地区等子标签后的上级语言(zh-Hant-TW -> zh-Hant -> zh)与兜底语言
+func (c *Catalog) Message(locale, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, candidate := range []string{normalizeLocale(locale), c.defaultLocale} {
+		for tag := strings.ToLower(candidate); tag != ""; tag = parentLocale(tag) {
+			if message, ok := c.messages[tag][key]; ok {
+				return message, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Match 根据 Accept-Language 请求头选择已加载的语言，按质量值(q)从高到低尝试：
+// 完全匹配，其次是上级语言(zh-TW 匹配 zh)，再次是同一语言的地区变体(en 匹配 en-US)；
+// 都不匹配时返回兜底语言
+func (c *Catalog) Match(acceptLanguage string) string {
+	return c.matchTags(parseAcceptLanguage(acceptLanguage))
+}
+
+// matchTags 按顺序为小写语言列表选择已加载的语言
+func (c *Catalog) matchTags(tags []string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, tag := range tags {
+		if tag == "*" {
+			break
+		}
+		for parent := tag; parent != ""; parent = parentLocale(parent) {
+			if locale, ok := c.locales[parent]; ok {
+				return locale
+			}
+		}
+		if locale := c.matchVariant(tag); locale != "" {
+			return locale
+		}
+	}
+	return c.defaultLocale
+}
+
+// matchVariant 返回 tag 的地区变体中名称最小的一个
+func (c *Catalog) matchVariant(tag string) string {
+	best := ""
+	for key, locale := range c.locales {
+		if strings.HasPrefix(key, tag+"-") && (best == "" || locale < best) {
+			best = locale
+		}
+	}
+	return best
+}
+
+// flattenMessages 把嵌套的消息展开为以 . 连接的键，非字符串的值转换为字符串
+func flattenMessages(prefix string, value any, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			flattenMessages(joinMessageKey(prefix, key), child, out)
+		}
+	case map[any]any:
+		for key, child := range v {
+			flattenMessages(joinMessageKey(prefix, fmt.Sprint(key)), child, out)
+		}
+	case nil:
+	default:
+		if prefix != "" {
+			out[prefix] = fmt.Sprint(v)
+		}
+	}
+}
+
+// joinMessageKey 连接消息键
+func joinMessageKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// normalizeLocale 规范化语言名称，把 zh_CN 转换为 zh-CN
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+}
+
+// parentLocale 返回去掉最后一个子标签后的语言，没有上级时返回空字符串
+func parentLocale(tag string) string {
+	if i := strings.LastIndexByte(tag, '-'); i > 0 {
+		return tag[:i]
+	}
+	return ""
+}
+
+// parseAcceptLanguage 解析 Accept-Language 请求头，返回按质量值从高到低排序的小写语言，忽略 q=0 的语言
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(normalizeLocale(tag))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil && v >= 0 && v <= 1 {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
+
+// GetLocalizedSceneCodeText 返回业务状态码在 language 下的消息，消息目录中没有时使用 GetSceneCodeText
+func GetLocalizedSceneCodeText(language string, code SceneCode) string {
+	if language != "" {
+		if message, ok := DefaultCatalog.Message(language, I18nSceneKeyPrefix+strconv.Itoa(int(code))); ok {
+			return message
+		}
+	}
+	return GetSceneCodeText(code)
+}
+
+// GetLocalizedStatusCodeText 返回 HTTP 状态码在 language 下的消息，消息目录中没有时使用 GetStatusCodeText
+func GetLocalizedStatusCodeText(language string, code StatusCode) string {
+	if language != "" {
+		if message, ok := DefaultCatalog.Message(language, I18nStatusKeyPrefix+strconv.Itoa(int(code))); ok {
+			return message
+		}
+	}
+	return GetStatusCodeText(code)
+}
+
+// Language 返回当前请求使用的语言：优先使用 SetLanguage 设置的语言，
+// 其次根据查询参数(Config.LanguageQueryKey)与 Accept-Language 在 DefaultCatalog 中匹配，都没有时返回兜底语言
+func (ctx *Context) Language() string {
+	if ctx.language != "" {
+		return ctx.language
+	}
+	return DefaultCatalog.matchTags(ctx.languageTags())
+}
+
+// languageTags 返回客户端期望的小写语言列表，查询参数指定的语言在 Accept-Language 之前
+func (ctx *Context) languageTags() []string {
+	if ctx.Request == nil {
+		return nil
+	}
+	tags := parseAcceptLanguage(ctx.Header(constants.HeaderAcceptLanguageKey))
+	if ctx.Engine == nil || ctx.Engine.Config.LanguageQueryKey == "" || ctx.Engine.Config.LanguageQueryKey == LanguageQueryDisabled {
+		return tags
+	}
+	if lang := strings.ToLower(normalizeLocale(ctx.QueryValue(ctx.Engine.Config.LanguageQueryKey))); lang != "" {
+		tags = append([]string{lang}, tags...)
+	}
+	return tags
+}
+
+// SetLanguage 指定当前请求使用的语言，例如根据用户设置或查询参数选择语言
+func (ctx *Context) SetLanguage(language string) {
+	ctx.language = normalizeLocale(language)
+}
+
+// Translate 返回消息键在当前请求语言下的消息，找不到时返回消息键本身
+func (ctx *Context) Translate(key string) string {
+	if message, ok := DefaultCatalog.Message(ctx.Language(), key); ok {
+		return message
+	}
+	return key
+}
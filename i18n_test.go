@@ -0,0 +1,133 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:06:48
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:09:51
+ * @FilePath: \gosh\i18n_test.go
+ * @Description: 测试多语言消息目录功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCatalogLoad 测试加载 JSON、YAML、TOML 与文件系统中的消息
+func TestCatalogLoad(t *testing.T) {
+	catalog := NewCatalog("en")
+	assert.NoError(t, catalog.Load("en", "json", []byte(`{"scene":{"1001":"Validation Error"},"hello":"Hello"}`)))
+	assert.NoError(t, catalog.Load("zh_CN", "yaml", []byte("scene:\n  1001: 参数校验错误\ncount: 3\n")))
+	assert.NoError(t, catalog.Load("ja", ".toml", []byte("[scene]\n1001 = \"検証エラー\"\n")))
+	assert.Error(t, catalog.Load("fr", "ini", []byte("a=b")))
+	assert.Error(t, catalog.Load("fr", "json", []byte("{")))
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "de.yml"), []byte("hello: Hallo\n"), 0o644))
+	assert.NoError(t, catalog.LoadFile(filepath.Join(dir, "de.yml")))
+	assert.NoError(t, catalog.LoadFS(fstest.MapFS{
+		"locales/ko.json": {Data: []byte(`{"hello":"안녕하세요"}`)},
+		"locales/README":  {Data: []byte("忽略")},
+	}, "locales/*.json"))
+	assert.Equal(t, []string{"de", "en", "ja", "ko", "zh-CN"}, catalog.Locales())
+
+	message, ok := catalog.Message("zh-CN", "scene.1001")
+	assert.True(t, ok)
+	assert.Equal(t, "参数校验错误", message)
+	message, _ = catalog.Message("zh-CN", "count")
+	assert.Equal(t, "3", message)
+	message, _ = catalog.Message("ja", "scene.1001")
+	assert.Equal(t, "検証エラー", message)
+
+	// 地区变体退回上级语言，再退回兜底语言
+	catalog.Add("de-AT", map[string]string{"bye": "Baba"})
+	message, _ = catalog.Message("de-AT", "hello")
+	assert.Equal(t, "Hallo", message)
+	message, _ = catalog.Message("zh-CN", "hello")
+	assert.Equal(t, "Hello", message)
+	_, ok = catalog.Message("zh-CN", "missing")
+	assert.False(t, ok)
+}
+
+// TestCatalogMatch 测试 Accept-Language 匹配与兜底
+func TestCatalogMatch(t *testing.T) {
+	catalog := NewCatalog("en")
+	for _, locale := range []string{"en", "zh-CN", "zh-TW", "pt-BR", "pt-PT"} {
+		catalog.Add(locale, map[string]string{"hello": locale})
+	}
+	cases := map[string]string{
+		"":                         "en",
+		"zh-CN,zh;q=0.9,en;q=0.8":  "zh-CN",
+		"zh-cn":                    "zh-CN",
+		"zh_TW":                    "zh-TW",
+		"en-GB":                    "en",
+		"zh":                       "zh-CN",
+		"pt":                       "pt-BR",
+		"fr, zh-TW;q=0.5":          "zh-TW",
+		"fr;q=1, en;q=0, zh;q=0.2": "zh-CN",
+		"de, *":                    "en",
+		"zh-CN;q=0":                "en",
+	}
+	for header, expected := range cases {
+		assert.Equal(t, expected, catalog.Match(header), header)
+	}
+
+	catalog.SetDefaultLocale("")
+	assert.Equal(t, "", catalog.Match("fr"))
+}
+
+// TestLocalizedResponses 测试默认错误处理与响应参数按客户端语言返回消息
+func TestLocalizedResponses(t *testing.T) {
+	engine := NewEngine(Config{})
+	engine.GET("/ok", func(ctx *Context) error {
+		return SendJSONResponse(ctx, &ResponseOption{SceneCode: ValidateError})
+	})
+	engine.GET("/private", func(ctx *Context) error {
+		return assert.AnError
+	})
+	engine.GET("/custom", func(ctx *Context) error {
+		return errorsx.ErrNotFound.WithMessage("用户不存在")
+	})
+	engine.GET("/override", func(ctx *Context) error {
+		ctx.SetLanguage("en")
+		assert.Equal(t, "Not Found", ctx.Translate("status.404"))
+		assert.Equal(t, "unknown.key", ctx.Translate("unknown.key"))
+		return errorsx.ErrSignedURLExpired
+	})
+
+	get := func(target, language string) map[string]any {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if language != "" {
+			req.Header.Set(constants.HeaderAcceptLanguageKey, language)
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		var body map[string]any
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		return body
+	}
+
+	// 没有 Accept-Language 时保持原有消息
+	assert.Equal(t, "Validation Error", get("/ok", "")["message"])
+	assert.Equal(t, "未找到请求的资源", get("/missing", "")["message"])
+
+	assert.Equal(t, "参数校验错误", get("/ok", "zh-CN,zh;q=0.9")["message"])
+	assert.Equal(t, "The requested resource was not found", get("/missing", "en-US,en;q=0.9")["message"])
+	assert.Equal(t, "内部服务器错误", get("/private", "zh")["message"])
+	assert.Equal(t, "用户不存在", get("/custom", "en")["message"])
+	assert.Equal(t, "The URL has expired", get("/override", "zh-CN")["message"])
+	assert.Equal(t, "Validation Error", get("/ok", "fr")["message"])
+	assert.Equal(t, "参数校验错误", get("/ok?lang=zh_CN", "en")["message"])
+
+	option := (&ResponseOption{HttpCode: StatusTooManyRequests, SceneCode: 4290, Language: "zh-CN"}).Merge()
+	assert.Equal(t, "请求过多", option.Message)
+}
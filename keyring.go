@@ -0,0 +1,180 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 13:20:08
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 13:20:08
+ * @FilePath: \gosh\keyring.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"sync"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 常量定义
+const (
+	minSecretKeyLength = 16 // 密钥最小长度（字节）
+)
+
+// KeyRing 支持轮换的密钥环
+// 第一个密钥为当前密钥，用于签名和加密；其余为旧密钥，只用于验证和解密，
+// 这样在轮换密钥期间，使用旧密钥签发的数据仍然有效
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys [][]byte
+}
+
+// NewKeyRing 创建密钥环，keys 按从新到旧的顺序排列
+func NewKeyRing(keys ...[]byte) (*KeyRing, error) {
+	ring := &KeyRing{}
+	if err := ring.SetKeys(keys...); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// SetKeys 替换全部密钥，keys 按从新到旧的顺序排列
+func (r *KeyRing) SetKeys(keys ...[]byte) error {
+	copied := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		if len(key) < minSecretKeyLength {
+			return errorsx.ErrSecretKeyTooShort
+		}
+		copied = append(copied, append([]byte(nil), key...))
+	}
+
+	r.mu.Lock()
+	r.keys = copied
+	r.mu.Unlock()
+	return nil
+}
+
+// Rotate 将新密钥设为当前密钥，原有密钥保留用于验证
+// keep 大于 0 时最多保留 keep 个密钥（包括新密钥），多余的旧密钥会被丢弃
+func (r *KeyRing) Rotate(key []byte, keep ...int) error {
+	if len(key) < minSecretKeyLength {
+		return errorsx.ErrSecretKeyTooShort
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := append([][]byte{append([]byte(nil), key...)}, r.keys...)
+	if len(keep) > 0 && keep[0] > 0 && len(keys) > keep[0] {
+		keys = keys[:keep[0]]
+	}
+	r.keys = keys
+	return nil
+}
+
+// Len 返回密钥数量
+func (r *KeyRing) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.keys)
+}
+
+// snapshot 返回当前密钥列表的快照
+func (r *KeyRing) snapshot() [][]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys
+}
+
+// deriveKey 按用途从主密钥派生子密钥，避免同一个密钥同时用于签名和加密
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gosh:" + purpose))
+	return mac.Sum(nil)
+}
+
+// computeMAC 计算 HMAC-SHA256
+func computeMAC(key []byte, purpose string, data []byte) []byte {
+	mac := hmac.New(sha256.New, deriveKey(key, purpose))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Sign 使用当前密钥计算数据的 HMAC-SHA256 签名，purpose 用于区分不同用途的签名
+func (r *KeyRing) Sign(purpose string, data []byte) ([]byte, error) {
+	keys := r.snapshot()
+	if len(keys) == 0 {
+		return nil, errorsx.ErrKeyRingEmpty
+	}
+	return computeMAC(keys[0], purpose, data), nil
+}
+
+// Verify 使用密钥环中的任意密钥验证签名
+func (r *KeyRing) Verify(purpose string, data, signature []byte) bool {
+	for _, key := range r.snapshot() {
+		if hmac.Equal(computeMAC(key, purpose, data), signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// newGCM 使用派生的 256 位密钥创建 AES-GCM
+func newGCM(key []byte, purpose string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(key, purpose))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt 使用当前密钥进行 AES-256-GCM 加密，返回 nonce 与密文拼接后的数据
+// additional 为附加认证数据，解密时必须提供相同的值
+func (r *KeyRing) Encrypt(purpose string, plaintext, additional []byte) ([]byte, error) {
+	keys := r.snapshot()
+	if len(keys) == 0 {
+		return nil, errorsx.ErrKeyRingEmpty
+	}
+	gcm, err := newGCM(keys[0], purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// Decrypt 依次尝试密钥环中的密钥解密
+func (r *KeyRing) Decrypt(purpose string, ciphertext, additional []byte) ([]byte, error) {
+	keys := r.snapshot()
+	if len(keys) == 0 {
+		return nil, errorsx.ErrKeyRingEmpty
+	}
+	for _, key := range keys {
+		gcm, err := newGCM(key, purpose)
+		if err != nil {
+			return nil, err
+		}
+		if len(ciphertext) < gcm.NonceSize() {
+			return nil, errorsx.ErrKeyRingDecrypt
+		}
+		nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+		if plaintext, err := gcm.Open(nil, nonce, sealed, additional); err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, errorsx.ErrKeyRingDecrypt
+}
+
+// KeyRing 返回引擎的密钥环，初始密钥来自 Config.SecretKeys
+func (engine *Engine) KeyRing() *KeyRing {
+	return engine.keyRing
+}
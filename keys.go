@@ -0,0 +1,129 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 15:12:09
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 15:12:09
+ * @FilePath: \gosh\keys.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// keysContext 挂载在请求上的 context.Context 视图
+// 首次写入键值时安装一次，之后的写入直接修改 map，下游通过 Request.Context().Value 即可读取
+type keysContext struct {
+	context.Context
+	mu     *sync.RWMutex
+	keys   map[string]any
+	values map[any]any
+}
+
+// Value 先查找请求级别的键值，找不到时交给父上下文
+func (c *keysContext) Value(key any) any {
+	c.mu.RLock()
+	if k, ok := key.(string); ok {
+		if value, exists := c.keys[k]; exists {
+			c.mu.RUnlock()
+			return value
+		}
+	} else if value, exists := c.values[key]; exists {
+		c.mu.RUnlock()
+		return value
+	}
+	c.mu.RUnlock()
+	return c.Context.Value(key)
+}
+
+// keysLock 返回保护键值存储的锁，直接构造的 Context 在首次使用时创建
+func (ctx *Context) keysLock() *sync.RWMutex {
+	if ctx.keysMu == nil {
+		ctx.keysMu = &sync.RWMutex{}
+	}
+	return ctx.keysMu
+}
+
+// ensureKeys 初始化键值存储并把 context.Context 视图挂载到请求上，调用方需要持有写锁
+func (ctx *Context) ensureKeys() {
+	if ctx.keysInstalled {
+		return
+	}
+	if ctx.Keys == nil {
+		ctx.Keys = make(map[string]any)
+	}
+	if ctx.contextValues == nil {
+		ctx.contextValues = make(map[any]any)
+	}
+	if ctx.Request != nil {
+		ctx.Request = ctx.Request.WithContext(&keysContext{
+			Context: ctx.Request.Context(),
+			mu:      ctx.keysMu,
+			keys:    ctx.Keys,
+			values:  ctx.contextValues,
+		})
+	}
+	ctx.keysInstalled = true
+}
+
+// Set 在当前请求中保存键值，值同时可以通过 Request.Context().Value(key) 读取
+func (ctx *Context) Set(key string, value any) {
+	mu := ctx.keysLock()
+	mu.Lock()
+	defer mu.Unlock()
+	ctx.ensureKeys()
+	ctx.Keys[key] = value
+}
+
+// Get 获取当前请求中保存的值
+func (ctx *Context) Get(key string) (value any, exists bool) {
+	mu := ctx.keysLock()
+	mu.RLock()
+	defer mu.RUnlock()
+	value, exists = ctx.Keys[key]
+	return
+}
+
+// MustGet 获取当前请求中保存的值，不存在时 panic
+func (ctx *Context) MustGet(key string) any {
+	if value, exists := ctx.Get(key); exists {
+		return value
+	}
+	panic(fmt.Sprintf("键 %q 不存在", key))
+}
+
+// GetAs 获取当前请求中保存的值并转换为指定类型，不存在或类型不匹配时 ok 为 false
+func GetAs[T any](ctx *Context, key string) (value T, ok bool) {
+	raw, exists := ctx.Get(key)
+	if !exists {
+		return value, false
+	}
+	value, ok = raw.(T)
+	return value, ok
+}
+
+// Value 实现 context.Context，先查找请求级别的键值，再查找请求的上下文
+func (ctx *Context) Value(key any) any {
+	if k, ok := key.(string); ok {
+		if value, exists := ctx.Get(k); exists {
+			return value
+		}
+	} else {
+		mu := ctx.keysLock()
+		mu.RLock()
+		value, exists := ctx.contextValues[key]
+		mu.RUnlock()
+		if exists {
+			return value
+		}
+	}
+	if ctx.Request == nil {
+		return nil
+	}
+	return ctx.Request.Context().Value(key)
+}
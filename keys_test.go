@@ -0,0 +1,107 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 15:31:48
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 15:31:48
+ * @FilePath: \gosh\keys_test.go
+ * @Description: 测试请求级别键值存储功能
+ */
+package gosh
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userKey struct{}
+
+// TestContextKeys 测试 Set、Get、MustGet 与 GetAs
+func TestContextKeys(t *testing.T) {
+	ctx := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+
+	_, exists := ctx.Get("missing")
+	assert.False(t, exists)
+	assert.Panics(t, func() { ctx.MustGet("missing") })
+
+	ctx.Set("user", "kamalyes")
+	ctx.Set("age", 18)
+	assert.Equal(t, "kamalyes", ctx.MustGet("user"))
+
+	age, ok := GetAs[int](ctx, "age")
+	assert.True(t, ok)
+	assert.Equal(t, 18, age)
+
+	_, ok = GetAs[string](ctx, "age")
+	assert.False(t, ok)
+	_, ok = GetAs[int](ctx, "missing")
+	assert.False(t, ok)
+}
+
+// TestContextKeysRequestView 测试请求上下文与键值存储保持同步
+func TestContextKeysRequestView(t *testing.T) {
+	ctx := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+
+	ctx.Set("first", 1)
+	req := ctx.Request
+	ctx.Set("second", 2)
+	ctx.SetContextValue(userKey{}, "kamalyes")
+	assert.Same(t, req, ctx.Request) // 只挂载一次，不再为每次写入创建新请求
+
+	reqCtx := ctx.Request.Context()
+	assert.Equal(t, 1, reqCtx.Value("first"))
+	assert.Equal(t, 2, reqCtx.Value("second"))
+	assert.Equal(t, "kamalyes", reqCtx.Value(userKey{}))
+	assert.Equal(t, 2, ctx.GetContextValue("second"))
+
+	// 下游库派生的上下文同样可以读取
+	derived, cancel := context.WithCancel(reqCtx)
+	defer cancel()
+	ctx.Set("third", 3)
+	assert.Equal(t, 3, derived.Value("third"))
+
+	// Context 本身也可以作为 context.Context 使用
+	var asContext context.Context = ctx
+	assert.Equal(t, "kamalyes", asContext.Value(userKey{}))
+}
+
+// TestContextKeysReset 测试上下文复用时清空键值
+func TestContextKeysReset(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/set", func(ctx *Context) error {
+		ctx.Set("flag", true)
+		return nil
+	})
+	engine.GET("/get", func(ctx *Context) error {
+		_, exists := ctx.Get("flag")
+		assert.False(t, exists)
+		assert.Nil(t, ctx.Request.Context().Value("flag"))
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/set", nil))
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/get", nil))
+	}
+}
+
+// TestContextKeysCopy 测试副本拥有独立的键值存储
+func TestContextKeysCopy(t *testing.T) {
+	ctx := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	ctx.Set("user", "kamalyes")
+
+	cp := ctx.Copy()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cp.Set("user", "copy")
+	}()
+	ctx.Set("other", true)
+	<-done
+
+	assert.Equal(t, "kamalyes", ctx.MustGet("user"))
+	assert.Equal(t, "copy", cp.MustGet("user"))
+}
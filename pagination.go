@@ -0,0 +1,327 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:12:35
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:29:46
+ * @FilePath: \gosh\pagination.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 分页参数默认值
+const (
+	defaultPageKey     = "page"
+	defaultSizeKey     = "size"
+	defaultCursorKey   = "cursor"
+	defaultLimitKey    = "limit"
+	defaultPageSize    = 20
+	defaultMaxPageSize = 100
+	cursorSignPurpose  = "pagination-cursor" // 分页游标的密钥用途
+)
+
+// PaginationConfig 分页参数配置
+type PaginationConfig struct {
+	PageKey     string        // 页码查询参数(默认page)
+	SizeKey     string        // 每页数量查询参数(默认size)
+	CursorKey   string        // 游标查询参数(默认cursor)
+	LimitKey    string        // 游标分页数量查询参数(默认limit)
+	DefaultSize int           // 没有指定时的每页数量(默认20)
+	MaxSize     int           // 每页数量上限(默认100)，超过时使用上限
+	MaxPage     int           // 页码上限，为 0 时不限制
+	CursorTTL   time.Duration // 游标有效期，为 0 时不过期
+}
+
+// withDefaults 填充默认值
+func (cfg PaginationConfig) withDefaults() PaginationConfig {
+	if cfg.PageKey == "" {
+		cfg.PageKey = defaultPageKey
+	}
+	if cfg.SizeKey == "" {
+		cfg.SizeKey = defaultSizeKey
+	}
+	if cfg.CursorKey == "" {
+		cfg.CursorKey = defaultCursorKey
+	}
+	if cfg.LimitKey == "" {
+		cfg.LimitKey = defaultLimitKey
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaultMaxPageSize
+	}
+	if cfg.DefaultSize <= 0 {
+		cfg.DefaultSize = defaultPageSize
+	}
+	if cfg.DefaultSize > cfg.MaxSize {
+		cfg.DefaultSize = cfg.MaxSize
+	}
+	return cfg
+}
+
+// paginationConfig 返回生效的分页配置：调用时传入的配置优先，其次是 Config.Pagination
+func (ctx *Context) paginationConfig(config []PaginationConfig) PaginationConfig {
+	if len(config) > 0 {
+		return config[0].withDefaults()
+	}
+	if ctx.Engine != nil && ctx.Engine.Config.Pagination != nil {
+		return ctx.Engine.Config.Pagination.withDefaults()
+	}
+	return PaginationConfig{}.withDefaults()
+}
+
+// parsePageSize 解析每页数量，没有指定时使用默认值，超过上限时使用上限
+func parsePageSize(value string, cfg PaginationConfig) (int, error) {
+	if value == "" {
+		return cfg.DefaultSize, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		return 0, errorsx.ErrInvalidPagination
+	}
+	if size > cfg.MaxSize {
+		size = cfg.MaxSize
+	}
+	return size, nil
+}
+
+// PageQuery 页码分页参数
+type PageQuery struct {
+	Page   int // 页码，从 1 开始
+	Size   int // 每页数量
+	config PaginationConfig
+}
+
+// Offset 返回查询的偏移量
+func (q PageQuery) Offset() int {
+	return (q.Page - 1) * q.Size
+}
+
+// PageQuery 从查询参数中解析页码与每页数量，参数不合法时返回 errorsx.ErrInvalidPagination
+func (ctx *Context) PageQuery(config ...PaginationConfig) (PageQuery, error) {
+	cfg := ctx.paginationConfig(config)
+	query := PageQuery{Page: 1, config: cfg}
+
+	if value := ctx.QueryValue(cfg.PageKey); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 || (cfg.MaxPage > 0 && page > cfg.MaxPage) {
+			return query, errorsx.ErrInvalidPagination
+		}
+		query.Page = page
+	}
+	size, err := parsePageSize(ctx.QueryValue(cfg.SizeKey), cfg)
+	if err != nil {
+		return query, err
+	}
+	// 偏移量溢出时会变成负数，直接拒绝
+	if query.Page-1 > math.MaxInt/size {
+		return query, errorsx.ErrInvalidPagination
+	}
+	query.Size = size
+	return query, nil
+}
+
+// CursorQuery 游标分页参数
+type CursorQuery struct {
+	Limit    int  // 每页数量
+	Backward bool // 游标来自上一页链接，需要查询位置之前的数据
+	position json.RawMessage
+	config   PaginationConfig
+}
+
+// HasCursor 返回请求是否带有游标，没有时从第一页开始查询
+func (q CursorQuery) HasCursor() bool {
+	return len(q.position) > 0
+}
+
+// Decode 把游标中的位置解析到 v，v 与签发游标时传入的位置类型相同
+func (q CursorQuery) Decode(v any) error {
+	if !q.HasCursor() {
+		return nil
+	}
+	return json.Unmarshal(q.position, v)
+}
+
+// cursorPayload 游标的内容
+type cursorPayload struct {
+	Position json.RawMessage `json:"p"`           // 位置
+	Backward bool            `json:"b,omitempty"` // 是否向前翻页
+	Expires  int64           `json:"e,omitempty"` // 过期时间(Unix 秒)
+}
+
+// CursorQuery 从查询参数中解析游标与每页数量，游标被篡改、过期或来自其他路由时返回 errorsx.ErrInvalidCursor
+func (ctx *Context) CursorQuery(config ...PaginationConfig) (CursorQuery, error) {
+	cfg := ctx.paginationConfig(config)
+	query := CursorQuery{config: cfg}
+
+	limit, err := parsePageSize(ctx.QueryValue(cfg.LimitKey), cfg)
+	if err != nil {
+		return query, err
+	}
+	query.Limit = limit
+
+	if cursor := ctx.QueryValue(cfg.CursorKey); cursor != "" {
+		payload, err := ctx.decodeCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		query.position = payload.Position
+		query.Backward = payload.Backward
+	}
+	return query, nil
+}
+
+// EncodeCursor 签发当前路由的游标，position 为查询位置(例如最后一条记录的排序键)，需要配置 Config.SecretKeys
+// 游标对客户端不透明，只能用于签发它的路由
+func (ctx *Context) EncodeCursor(position any, backward bool) (string, error) {
+	return ctx.encodeCursor(position, backward, ctx.paginationConfig(nil).CursorTTL)
+}
+
+// encodeCursor 签发游标：载荷.签名，签名内容包含路由，防止游标被挪用到其他接口
+func (ctx *Context) encodeCursor(position any, backward bool, ttl time.Duration) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	payload := cursorPayload{Position: raw, Backward: backward}
+	if ttl > 0 {
+		payload.Expires = time.Now().Add(ttl).Unix()
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	signature, err := ctx.Engine.KeyRing().Sign(cursorSignPurpose, []byte(ctx.FullPath()+"\n"+encoded))
+	if err != nil {
+		return "", err
+	}
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// decodeCursor 校验并解析游标
+func (ctx *Context) decodeCursor(cursor string) (*cursorPayload, error) {
+	encoded, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found || ctx.Engine == nil {
+		return nil, errorsx.ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !ctx.Engine.KeyRing().Verify(cursorSignPurpose, []byte(ctx.FullPath()+"\n"+encoded), signature) {
+		return nil, errorsx.ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errorsx.ErrInvalidCursor
+	}
+	// 签名通过后内容可信
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || len(payload.Position) == 0 {
+		return nil, errorsx.ErrInvalidCursor
+	}
+	if payload.Expires > 0 && time.Now().Unix() > payload.Expires {
+		return nil, errorsx.ErrInvalidCursor
+	}
+	return &payload, nil
+}
+
+// PageResponse 创建页码分页响应，并设置 first、prev、next、last 的 Link 响应头(RFC 8288)
+func PageResponse[T any](ctx *Context, query PageQuery, items []T, total int64) *Response[[]T] {
+	if items == nil {
+		items = []T{}
+	}
+	pagination := NewPagination(query.Page, query.Size, total)
+
+	cfg := query.config.withDefaults()
+	link := func(page int) string {
+		return ctx.paginationURL(map[string]string{cfg.PageKey: strconv.Itoa(page), cfg.SizeKey: strconv.Itoa(query.Size)})
+	}
+	links := []string{formatLink(link(1), "first")}
+	if query.Page > 1 {
+		links = append(links, formatLink(link(query.Page-1), "prev"))
+	}
+	if query.Page < pagination.TotalPages {
+		links = append(links, formatLink(link(query.Page+1), "next"))
+	}
+	if pagination.TotalPages > 0 {
+		links = append(links, formatLink(link(pagination.TotalPages), "last"))
+	}
+	ctx.Writer().Header().Set(constants.HeaderLinkKey, strings.Join(links, ", "))
+
+	return NewResponse(items).WithPagination(pagination)
+}
+
+// CursorPageResponse 创建游标分页响应，next、prev 为下一页与上一页的查询位置，为 nil 时表示没有；
+// 响应中带有签发的游标，并设置 next、prev 的 Link 响应头(RFC 8288)
+func CursorPageResponse[T any](ctx *Context, query CursorQuery, items []T, next, prev any) (*Response[[]T], error) {
+	if items == nil {
+		items = []T{}
+	}
+	cfg := query.config.withDefaults()
+	pagination := &Pagination{Limit: query.Limit}
+
+	var links []string
+	if next != nil {
+		cursor, err := ctx.encodeCursor(next, false, cfg.CursorTTL)
+		if err != nil {
+			return nil, err
+		}
+		pagination.Next = cursor
+		links = append(links, formatLink(ctx.paginationURL(map[string]string{cfg.CursorKey: cursor, cfg.LimitKey: strconv.Itoa(query.Limit)}), "next"))
+	}
+	if prev != nil {
+		cursor, err := ctx.encodeCursor(prev, true, cfg.CursorTTL)
+		if err != nil {
+			return nil, err
+		}
+		pagination.Prev = cursor
+		links = append(links, formatLink(ctx.paginationURL(map[string]string{cfg.CursorKey: cursor, cfg.LimitKey: strconv.Itoa(query.Limit)}), "prev"))
+	}
+	if len(links) > 0 {
+		ctx.Writer().Header().Set(constants.HeaderLinkKey, strings.Join(links, ", "))
+	}
+
+	return NewResponse(items).WithPagination(pagination), nil
+}
+
+// SendPage 输出页码分页结果
+func SendPage[T any](ctx *Context, query PageQuery, items []T, total int64) error {
+	return SendResponse(ctx, PageResponse(ctx, query, items, total))
+}
+
+// SendCursorPage 输出游标分页结果，需要总数时使用 CursorPageResponse 设置 Pagination.Total
+func SendCursorPage[T any](ctx *Context, query CursorQuery, items []T, next, prev any) error {
+	resp, err := CursorPageResponse(ctx, query, items, next, prev)
+	if err != nil {
+		return err
+	}
+	return SendResponse(ctx, resp)
+}
+
+// paginationURL 返回替换了分页参数的当前请求地址，其余查询参数保持不变
+func (ctx *Context) paginationURL(params map[string]string) string {
+	query := ctx.Request.URL.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u := url.URL{Path: ctx.Request.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// formatLink 格式化一个 Link 响应头的值
+func formatLink(uri, rel string) string {
+	return "<" + uri + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,184 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:13:05
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:29:46
+ * @FilePath: \gosh\pagination_test.go
+ * @Description: 测试分页功能
+ */
+package gosh
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPageQuery 测试页码分页参数的解析与上下限
+func TestPageQuery(t *testing.T) {
+	newContext := func(target string) *Context {
+		return &Context{Engine: NewEngine(Config{Pagination: &PaginationConfig{MaxPage: 50}}), Request: httptest.NewRequest(http.MethodGet, target, nil)}
+	}
+
+	query, err := newContext("/").PageQuery()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, query.Page)
+	assert.Equal(t, 20, query.Size)
+	assert.Equal(t, 0, query.Offset())
+
+	query, err = newContext("/?page=3&size=500").PageQuery()
+	assert.NoError(t, err)
+	assert.Equal(t, 100, query.Size)
+	assert.Equal(t, 200, query.Offset())
+
+	for _, target := range []string{"/?page=0", "/?page=abc", "/?page=51", "/?size=0", "/?size=-1"} {
+		_, err = newContext(target).PageQuery()
+		assert.ErrorIs(t, err, errorsx.ErrInvalidPagination, target)
+	}
+
+	// 没有页码上限时，偏移量溢出的页码同样无效
+	_, err = newContext("/?page=9223372036854775807").PageQuery(PaginationConfig{})
+	assert.ErrorIs(t, err, errorsx.ErrInvalidPagination)
+	query, err = newContext("/?page=1000000").PageQuery(PaginationConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, 19999980, query.Offset())
+
+	// 调用时传入的配置优先
+	query, err = newContext("/?p=2&per_page=80").PageQuery(PaginationConfig{PageKey: "p", SizeKey: "per_page", DefaultSize: 10, MaxSize: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, query.Page)
+	assert.Equal(t, 50, query.Size)
+
+	cursorQuery, err := newContext("/?limit=5").CursorQuery()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, cursorQuery.Limit)
+	assert.False(t, cursorQuery.HasCursor())
+}
+
+// TestSendPage 测试页码分页响应与 Link 响应头
+func TestSendPage(t *testing.T) {
+	engine := NewEngine(Config{})
+	engine.GET("/users", func(ctx *Context) error {
+		query, err := ctx.PageQuery()
+		if err != nil {
+			return err
+		}
+		var users []string
+		if query.Page == 2 {
+			users = []string{"k", "l"}
+		}
+		return SendPage(ctx, query, users, 35)
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users?page=2&size=10&q=go", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `</users?page=1&q=go&size=10>; rel="first", `+
+		`</users?page=1&q=go&size=10>; rel="prev", `+
+		`</users?page=3&q=go&size=10>; rel="next", `+
+		`</users?page=4&q=go&size=10>; rel="last"`, recorder.Header().Get(constants.HeaderLinkKey))
+	assert.JSONEq(t, `{
+		"code": 200,
+		"message": "Success",
+		"data": ["k", "l"],
+		"pagination": {"page": 2, "size": 10, "total": 35, "total_pages": 4}
+	}`, recorder.Body.String())
+
+	// 超出范围的页返回空数组
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users?page=9&size=10", nil))
+	assert.Contains(t, recorder.Body.String(), `"data":[]`)
+	assert.NotContains(t, recorder.Header().Get(constants.HeaderLinkKey), `rel="next"`)
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users?page=x", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "分页参数不合法")
+}
+
+// TestCursorPagination 测试游标的签发、翻页与篡改检测
+func TestCursorPagination(t *testing.T) {
+	engine := NewEngine(Config{SecretKeys: [][]byte{[]byte("0123456789abcdef")}})
+	list := func(ctx *Context) error {
+		query, err := ctx.CursorQuery()
+		if err != nil {
+			return err
+		}
+		after := 0
+		if err := query.Decode(&after); err != nil {
+			return err
+		}
+
+		var items []int
+		for id := after + 1; id <= 25 && len(items) < query.Limit; id++ {
+			items = append(items, id)
+		}
+		var next, prev any
+		if len(items) > 0 && items[len(items)-1] < 25 {
+			next = items[len(items)-1]
+		}
+		if query.HasCursor() {
+			prev = after
+		}
+		return SendCursorPage(ctx, query, items, next, prev)
+	}
+	engine.GET("/orders", list)
+	engine.GET("/invoices", list)
+
+	type page struct {
+		Data       []int      `json:"data"`
+		Pagination Pagination `json:"pagination"`
+	}
+	get := func(target string) (*httptest.ResponseRecorder, page) {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		var body page
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		return recorder, body
+	}
+
+	recorder, first := get("/orders?limit=10")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, first.Data)
+	assert.Equal(t, 10, first.Pagination.Limit)
+	assert.NotEmpty(t, first.Pagination.Next)
+	assert.Empty(t, first.Pagination.Prev)
+
+	// 按 Link 响应头翻页
+	matches := regexp.MustCompile(`^<([^>]+)>; rel="next"$`).FindStringSubmatch(recorder.Header().Get(constants.HeaderLinkKey))
+	if !assert.Len(t, matches, 2) {
+		return
+	}
+	recorder, second := get(matches[1])
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []int{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, second.Data)
+	assert.NotEmpty(t, second.Pagination.Prev)
+	assert.Contains(t, recorder.Header().Get(constants.HeaderLinkKey), `rel="prev"`)
+
+	_, last := get("/orders?limit=10&cursor=" + second.Pagination.Next)
+	assert.Equal(t, []int{21, 22, 23, 24, 25}, last.Data)
+	assert.Empty(t, last.Pagination.Next)
+
+	// 篡改、挪用到其他路由与过期的游标都无效
+	tampered := "eyJwIjo5OTl9." + first.Pagination.Next[len(first.Pagination.Next)-43:]
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"p":10,"e":1}`))
+	signature, _ := engine.KeyRing().Sign(cursorSignPurpose, []byte("/orders\n"+payload))
+	expired := payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+	for _, target := range []string{
+		"/orders?cursor=" + tampered,
+		"/orders?cursor=garbage",
+		"/invoices?cursor=" + first.Pagination.Next,
+		"/orders?cursor=" + expired,
+	} {
+		recorder, _ = get(target)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, target)
+		assert.Contains(t, recorder.Body.String(), "分页游标无效或已过期", target)
+	}
+}
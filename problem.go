@@ -0,0 +1,134 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 21:05:42
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:07:39
+ * @FilePath: \gosh\problem.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// 常量定义
+const (
+	ProblemTypeDefault = "about:blank" // 没有更具体的问题类型时使用的 type
+)
+
+// ProblemDetails RFC 7807 问题详情文档
+type ProblemDetails struct {
+	Type       string         // 问题类型 URI(默认about:blank)
+	Title      string         // 问题类型的简短说明(默认为状态码对应的标准文本)
+	Status     int            // HTTP 状态码
+	Detail     string         // 本次问题的具体说明
+	Instance   string         // 发生问题的资源(默认为请求路径)
+	Extensions map[string]any // 扩展成员，与标准成员平级输出，不能覆盖标准成员
+}
+
+// MarshalJSON 把扩展成员与标准成员输出在同一层级
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		doc[key] = value
+	}
+	doc["type"] = p.Type
+	doc["title"] = p.Title
+	doc["status"] = p.Status
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	} else {
+		delete(doc, "detail")
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	} else {
+		delete(doc, "instance")
+	}
+	return json.Marshal(doc)
+}
+
+// WriteProblem 以 application/problem+json 格式写出问题详情，未设置的标准成员使用默认值
+func (ctx *Context) WriteProblem(problem *ProblemDetails) error {
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if problem.Type == "" {
+		problem.Type = ProblemTypeDefault
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.Instance == "" && ctx.Request != nil && ctx.Request.URL != nil {
+		problem.Instance = ctx.Request.URL.Path
+	}
+
+	buf, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	ctx.Status = problem.Status
+	ctx.setContentType(constants.ContentTypeProblemJSON)
+	ctx.ResponseWriter.WriteHeader(problem.Status)
+	_, err = ctx.ResponseWriter.Write(buf)
+	return err
+}
+
+// SendErrorResponse 生成错误响应
+// 默认与 SendJSONResponse 相同，开启 Config.ProblemDetails 时输出 application/problem+json：
+// Message 作为 detail，业务状态码作为 code 扩展成员；Data 为 map[string]string(例如参数校验结果)时作为 errors，
+// 为 map[string]any 时展开为扩展成员，其他类型作为 data；存在链路与请求 ID 时附带 trace_id 与 request_id
+func SendErrorResponse(c *Context, respOption *ResponseOption) error {
+	if c.Engine == nil || !c.Engine.Config.ProblemDetails {
+		return SendJSONResponse(c, respOption)
+	}
+	if respOption == nil {
+		respOption = &ResponseOption{}
+	}
+	if respOption.Language == "" {
+		respOption.Language = c.Language()
+	}
+	respOption.Merge()
+	return c.WriteProblem(newProblemDetails(c, respOption))
+}
+
+// newProblemDetails 根据响应参数构建问题详情
+func newProblemDetails(c *Context, respOption *ResponseOption) *ProblemDetails {
+	problem := &ProblemDetails{
+		Type:       ProblemTypeDefault,
+		Status:     int(respOption.HttpCode),
+		Detail:     respOption.Message,
+		Extensions: map[string]any{"code": respOption.SceneCode},
+	}
+	if base := c.Engine.Config.ProblemTypeBase; base != "" {
+		problem.Type = strings.TrimSuffix(base, "/") + "/" + strconv.Itoa(int(respOption.SceneCode))
+	}
+
+	switch data := respOption.Data.(type) {
+	case nil:
+	case map[string]string:
+		problem.Extensions["errors"] = data
+	case map[string]any:
+		for key, value := range data {
+			problem.Extensions[key] = value
+		}
+	default:
+		problem.Extensions["data"] = data
+	}
+
+	if sc := c.Span().SpanContext(); sc.TraceID.IsValid() {
+		problem.Extensions["trace_id"] = sc.TraceID.String()
+	}
+	if c.requestID != "" {
+		problem.Extensions["request_id"] = c.requestID
+	}
+	return problem
+}
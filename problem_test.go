@@ -0,0 +1,89 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 21:18:30
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:46:07
+ * @FilePath: \gosh\problem_test.go
+ * @Description: 测试 problem+json 错误响应功能
+ */
+package gosh
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProblemDetailsErrors 测试默认错误处理输出 problem+json
+func TestProblemDetailsErrors(t *testing.T) {
+	engine := NewEngine(Config{ProblemDetails: true})
+	engine.GET("/users", func(ctx *Context) error {
+		return errorsx.New(http.StatusConflict, "用户名已被占用").
+			WithDetail("field", "username").
+			WithDetail("status", "ignored") // 不能覆盖标准成员
+	})
+	engine.GET("/private", func(ctx *Context) error {
+		return errors.New("password=secret")
+	})
+
+	recorder, doc := serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/users?debug=1", nil))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Equal(t, constants.ContentTypeProblemJSON, recorder.Header().Get(constants.HeaderContentTypeKey))
+	assert.Equal(t, ProblemTypeDefault, doc["type"])
+	assert.Equal(t, "Conflict", doc["title"])
+	assert.Equal(t, float64(http.StatusConflict), doc["status"])
+	assert.Equal(t, "用户名已被占用", doc["detail"])
+	assert.Equal(t, "/users", doc["instance"])
+	assert.Equal(t, float64(http.StatusConflict), doc["code"])
+	assert.Equal(t, "username", doc["field"])
+
+	recorder, doc = serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/private", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "Internal Server Error", doc["title"])
+	assert.NotContains(t, recorder.Body.String(), "secret")
+
+	// 未注册的路由同样使用 problem+json
+	recorder, doc = serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, float64(http.StatusNotFound), doc["status"])
+}
+
+// TestProblemDetailsExtensions 测试 type 前缀、校验错误、链路与请求 ID 扩展成员
+func TestProblemDetailsExtensions(t *testing.T) {
+	engine := NewEngine(Config{ProblemDetails: true, ProblemTypeBase: "https://errors.example.com/"})
+	engine.Use(RequestID(), Tracing(TracingConfig{}))
+	engine.POST("/orders", func(ctx *Context) error {
+		Gen400xResponse(ctx, &ResponseOption{Data: map[string]string{"amount": "amount必须大于0"}})
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(constants.TraceIdKey, "req-7")
+	req.Header.Set(constants.HeaderTraceParentKey, testTraceParent)
+	recorder, doc := serveJSON(t, engine, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "https://errors.example.com/400", doc["type"])
+	assert.Equal(t, map[string]any{"amount": "amount必须大于0"}, doc["errors"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", doc["trace_id"])
+	assert.Equal(t, "req-7", doc["request_id"])
+}
+
+// TestProblemDetailsDisabled 测试未开启时保持 code/message/data 格式
+func TestProblemDetailsDisabled(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/", func(ctx *Context) error {
+		Gen500xResponse(ctx, nil)
+		return nil
+	})
+
+	recorder, doc := serveJSON(t, engine, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, constants.ContentTypeJSON, recorder.Header().Get(constants.HeaderContentTypeKey))
+	assert.Equal(t, float64(Fail), doc["code"])
+	assert.NotContains(t, doc, "type")
+}
@@ -0,0 +1,260 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 11:52:17
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 11:52:17
+ * @FilePath: \gosh\proxy.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// defaultTrustedHeaders 默认按顺序读取的客户端 IP 请求头
+var defaultTrustedHeaders = []string{
+	constants.HeaderXForwardedForKey,
+	constants.HeaderXRealIPKey,
+}
+
+// SetTrustedProxies 设置可信代理列表，支持 CIDR（如 10.0.0.0/8）和单个 IP
+// 传入 nil 表示不信任任何代理，此时 ClientIP 直接返回连接的对端地址
+func (engine *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs, err := parseTrustedProxies(proxies)
+	if err != nil {
+		return err
+	}
+	engine.Config.TrustedProxies = proxies
+	engine.trustedCIDRs = cidrs
+	return nil
+}
+
+// parseTrustedProxies 将可信代理配置解析为网段
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的可信代理地址: %s", proxy)
+			}
+			if ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("无效的可信代理网段: %s", proxy)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+// isTrustedProxy 判断 IP 是否属于可信代理
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range engine.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedHeaders 返回用于解析客户端 IP 的请求头
+func (engine *Engine) trustedHeaders() []string {
+	if len(engine.Config.TrustedHeaders) > 0 {
+		return engine.Config.TrustedHeaders
+	}
+	return defaultTrustedHeaders
+}
+
+// RemoteIP 返回 TCP 连接对端的 IP，不解析任何请求头
+func (ctx *Context) RemoteIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(ctx.Request.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(ctx.Request.RemoteAddr)
+	}
+	return host
+}
+
+// fromTrustedProxy 判断请求是否由可信代理转发
+func (ctx *Context) fromTrustedProxy() bool {
+	return ctx.Engine != nil && ctx.Engine.isTrustedProxy(net.ParseIP(ctx.RemoteIP()))
+}
+
+// ClientIP 获取客户端真实 IP
+// 只有当连接对端属于 Config.TrustedProxies 时，才会按 Config.TrustedHeaders 的顺序读取转发请求头；
+// X-Forwarded-For 与 Forwarded 从右向左解析，跳过可信代理，返回第一个不可信的地址
+func (ctx *Context) ClientIP() string {
+	remoteIP := ctx.RemoteIP()
+	if !ctx.fromTrustedProxy() {
+		return remoteIP
+	}
+
+	for _, header := range ctx.Engine.trustedHeaders() {
+		values := ctx.Request.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		var chain []string
+		switch http.CanonicalHeaderKey(header) {
+		case constants.HeaderXForwardedForKey:
+			chain = splitHeaderList(values)
+		case constants.HeaderForwardedKey:
+			for _, element := range parseForwarded(values) {
+				chain = append(chain, element["for"])
+			}
+		default:
+			// X-Real-IP 以及 CDN 提供的请求头只包含单个地址
+			chain = []string{strings.TrimSpace(values[0])}
+		}
+
+		if ip, ok := ctx.Engine.resolveForwardedChain(chain); ok {
+			return ip
+		}
+	}
+	return remoteIP
+}
+
+// resolveForwardedChain 从右向左遍历转发链，返回第一个不可信的地址
+func (engine *Engine) resolveForwardedChain(chain []string) (string, bool) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(stripPort(chain[i]))
+		if ip == nil {
+			return "", false // 链中存在无法解析的地址，整条链都不可信
+		}
+		if i == 0 || !engine.isTrustedProxy(ip) {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// Scheme 返回客户端使用的协议（http 或 https）
+// 只有可信代理设置的 X-Forwarded-Proto 或 Forwarded proto 才会生效
+func (ctx *Context) Scheme() string {
+	if ctx.fromTrustedProxy() {
+		if proto := lastHeaderListValue(ctx.Request.Header.Values(constants.HeaderXForwardedProtoKey)); proto != "" {
+			return strings.ToLower(proto)
+		}
+		if proto := lastForwardedValue(ctx.Request.Header.Values(constants.HeaderForwardedKey), "proto"); proto != "" {
+			return strings.ToLower(proto)
+		}
+	}
+	if ctx.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host 返回客户端请求的主机名（可能包含端口）
+// 只有可信代理设置的 X-Forwarded-Host 或 Forwarded host 才会生效
+func (ctx *Context) Host() string {
+	if ctx.fromTrustedProxy() {
+		if host := lastHeaderListValue(ctx.Request.Header.Values(constants.HeaderXForwardedHostKey)); host != "" {
+			return host
+		}
+		if host := lastForwardedValue(ctx.Request.Header.Values(constants.HeaderForwardedKey), "host"); host != "" {
+			return host
+		}
+	}
+	return ctx.Request.Host
+}
+
+// splitHeaderList 拆分以逗号分隔的多值请求头
+func splitHeaderList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// lastHeaderListValue 返回多值请求头中最右侧的值，即离服务端最近的代理写入的值
+func lastHeaderListValue(values []string) string {
+	items := splitHeaderList(values)
+	if len(items) == 0 {
+		return ""
+	}
+	return items[len(items)-1]
+}
+
+// lastForwardedValue 返回 Forwarded 请求头中最右侧包含指定参数的值
+func lastForwardedValue(values []string, key string) string {
+	elements := parseForwarded(values)
+	for i := len(elements) - 1; i >= 0; i-- {
+		if value, ok := elements[i][key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseForwarded 解析 RFC 7239 Forwarded 请求头，每个转发节点对应一个参数表
+func parseForwarded(values []string) []map[string]string {
+	var elements []map[string]string
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			params := make(map[string]string)
+			for _, pair := range splitQuoted(element, ';') {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found {
+					continue
+				}
+				params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+			if len(params) > 0 {
+				elements = append(elements, params)
+			}
+		}
+	}
+	return elements
+}
+
+// splitQuoted 按分隔符拆分字符串，忽略引号内的分隔符
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case sep:
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripPort 去掉地址中的端口和 IPv6 方括号，如 "[2001:db8::1]:80" -> "2001:db8::1"
+func stripPort(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
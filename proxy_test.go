@@ -0,0 +1,108 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 12:14:05
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 12:14:05
+ * @FilePath: \gosh\proxy_test.go
+ * @Description: 测试可信代理与 ClientIP 功能
+ */
+package gosh
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newProxyContext 创建带指定对端地址和请求头的上下文
+func newProxyContext(engine *Engine, remoteAddr string, headers map[string]string) *Context {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Add(key, value)
+	}
+	return &Context{Engine: engine, Request: req}
+}
+
+// TestClientIPUntrustedRemote 测试不可信来源不会读取转发请求头
+func TestClientIPUntrustedRemote(t *testing.T) {
+	engine := NewEngine()
+	ctx := newProxyContext(engine, "203.0.113.9:5000", map[string]string{
+		"X-Forwarded-For": "1.2.3.4",
+		"X-Real-IP":       "5.6.7.8",
+	})
+	assert.Equal(t, "203.0.113.9", ctx.ClientIP())
+	assert.Equal(t, "example.com", ctx.Host())
+	assert.Equal(t, "http", ctx.Scheme())
+}
+
+// TestClientIPForwardedForChain 测试从右向左解析 X-Forwarded-For
+func TestClientIPForwardedForChain(t *testing.T) {
+	engine := NewEngine(Config{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+
+	// 客户端伪造的 9.9.9.9 位于最左侧，应返回第一个不可信的地址
+	ctx := newProxyContext(engine, "10.0.0.2:443", map[string]string{
+		"X-Forwarded-For": "9.9.9.9, 198.51.100.7, 192.168.1.1",
+	})
+	assert.Equal(t, "198.51.100.7", ctx.ClientIP())
+
+	// 全部为可信代理时返回最左侧地址
+	ctx = newProxyContext(engine, "10.0.0.2:443", map[string]string{
+		"X-Forwarded-For": "10.1.1.1, 192.168.1.1",
+	})
+	assert.Equal(t, "10.1.1.1", ctx.ClientIP())
+
+	// 无法解析的链回退到下一个请求头
+	ctx = newProxyContext(engine, "10.0.0.2:443", map[string]string{
+		"X-Forwarded-For": "bogus, 10.1.1.1",
+		"X-Real-IP":       "198.51.100.8",
+	})
+	assert.Equal(t, "198.51.100.8", ctx.ClientIP())
+}
+
+// TestClientIPForwardedHeader 测试 RFC 7239 Forwarded 与 CDN 请求头
+func TestClientIPForwardedHeader(t *testing.T) {
+	engine := NewEngine(Config{
+		TrustedProxies: []string{"10.0.0.0/8"},
+		TrustedHeaders: []string{"CF-Connecting-IP", "Forwarded"},
+	})
+
+	ctx := newProxyContext(engine, "10.0.0.2:443", map[string]string{
+		"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=api.example.com, for=10.0.0.3`,
+	})
+	assert.Equal(t, "2001:db8::1", ctx.ClientIP())
+	assert.Equal(t, "https", ctx.Scheme())
+	assert.Equal(t, "api.example.com", ctx.Host())
+
+	ctx = newProxyContext(engine, "10.0.0.2:443", map[string]string{
+		"CF-Connecting-IP": "198.51.100.20",
+		"Forwarded":        "for=198.51.100.21",
+	})
+	assert.Equal(t, "198.51.100.20", ctx.ClientIP())
+}
+
+// TestSchemeAndHost 测试 X-Forwarded-Proto 与 X-Forwarded-Host
+func TestSchemeAndHost(t *testing.T) {
+	engine := NewEngine(Config{TrustedProxies: []string{"127.0.0.1"}})
+	ctx := newProxyContext(engine, "127.0.0.1:8080", map[string]string{
+		"X-Forwarded-Proto": "http, HTTPS",
+		"X-Forwarded-Host":  "evil.com, shop.example.com",
+	})
+	assert.Equal(t, "https", ctx.Scheme())
+	assert.Equal(t, "shop.example.com", ctx.Host())
+
+	ctx = newProxyContext(engine, "203.0.113.1:8080", map[string]string{"X-Forwarded-Proto": "http"})
+	ctx.Request.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https", ctx.Scheme())
+}
+
+// TestSetTrustedProxiesInvalid 测试非法的可信代理配置
+func TestSetTrustedProxiesInvalid(t *testing.T) {
+	engine := NewEngine()
+	assert.Error(t, engine.SetTrustedProxies([]string{"not-an-ip"}))
+	assert.Error(t, engine.SetTrustedProxies([]string{"10.0.0.0/33"}))
+	assert.Panics(t, func() { NewEngine(Config{TrustedProxies: []string{"bad"}}) })
+}
@@ -0,0 +1,131 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 22:52:19
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 22:52:19
+ * @FilePath: \gosh\recovery.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// RecoveryHandlerFunc 自定义 panic 恢复处理器，在记录日志之后调用，负责写出响应
+// 客户端已断开连接时不会调用
+type RecoveryHandlerFunc func(ctx *Context, err any)
+
+// sensitiveHeaders 记录请求内容时需要隐藏的请求头
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// stackPanic 在其他 goroutine 中捕获并转交给 Recovery 的 panic，保留原始堆栈
+type stackPanic struct {
+	value any
+	stack []byte
+}
+
+// String 没有开启 Recovery 时由 net/http 输出，包含原始堆栈
+func (p *stackPanic) String() string {
+	return fmt.Sprintf("%v\n%s", p.value, p.stack)
+}
+
+// recoverFromPanic 处理panic：记录堆栈与脱敏后的请求内容，客户端断开连接时不写出响应
+func (engine *Engine) recoverFromPanic(ctx *Context) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if err == http.ErrAbortHandler {
+		panic(err) // 交给 net/http 中止连接，不记录日志
+	}
+
+	var stack []byte
+	if sp, ok := err.(*stackPanic); ok {
+		err, stack = sp.value, sp.stack
+	}
+	dump := dumpRequest(ctx.Request)
+	brokenPipe := isBrokenPipe(err)
+	engine.logPanic(ctx, err, dump, stack, brokenPipe)
+
+	if brokenPipe {
+		ctx.Abort()
+		ctx.Error = errorsx.From(fmt.Errorf("%v", err))
+		return
+	}
+	if handler := engine.Config.RecoveryHandler; handler != nil {
+		ctx.Abort()
+		ctx.Error = errorsx.From(fmt.Errorf("%v", err))
+		handler(ctx, err)
+		return
+	}
+	// 创建私有错误，panic 的内容不会返回给客户端
+	handleError(ctx, engine, errorsx.From(fmt.Errorf("%v", err)), http.StatusInternalServerError)
+}
+
+// logPanic 通过配置的 Logger 记录 panic，没有配置时输出到标准库日志
+func (engine *Engine) logPanic(ctx *Context, err any, dump, stack []byte, brokenPipe bool) {
+	if engine.Config.Zap == nil {
+		if brokenPipe {
+			ctx.logPrintln("客户端已断开连接:", err)
+			return
+		}
+		if stack == nil {
+			stack = debug.Stack()
+		}
+		ctx.logPrintln(fmt.Sprintf("从 panic 中恢复: %v\n%s\n%s", err, dump, stack))
+		return
+	}
+
+	logger := ctx.Logger()
+	switch {
+	case brokenPipe:
+		logger.LogBrokenPipe(err, dump)
+	case stack != nil:
+		logger.LogRecoveryWithStack(err, dump, stack)
+	default:
+		logger.LogRecovery(err, dump)
+	}
+}
+
+// dumpRequest 输出不含请求体的请求内容，敏感请求头被替换为 *
+func dumpRequest(req *http.Request) []byte {
+	if req == nil {
+		return nil
+	}
+	r := *req
+	r.Header = req.Header.Clone()
+	for _, key := range sensitiveHeaders {
+		if r.Header.Get(key) != "" {
+			r.Header.Set(key, "*")
+		}
+	}
+	dump, err := httputil.DumpRequest(&r, false)
+	if err != nil {
+		return nil
+	}
+	return dump
+}
+
+// isBrokenPipe 判断 panic 是否由客户端断开连接(EPIPE/ECONNRESET)引起，这时已经无法写出响应
+func isBrokenPipe(err any) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(e, syscall.EPIPE) || errors.Is(e, syscall.ECONNRESET) {
+		return true
+	}
+	message := strings.ToLower(e.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
@@ -0,0 +1,125 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 23:08:55
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 23:08:55
+ * @FilePath: \gosh\recovery_test.go
+ * @Description: 测试 panic 恢复功能
+ */
+package gosh
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newRecoveryEngine 创建使用观察者日志的引擎
+func newRecoveryEngine(config Config) (*Engine, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	config.Zap = nopLogger()
+	config.Zap.Logger = zap.New(core)
+	return NewEngine(config), logs
+}
+
+// TestRecoveryLogsStack 测试记录堆栈与脱敏的请求内容
+func TestRecoveryLogsStack(t *testing.T) {
+	engine, logs := newRecoveryEngine(Config{Recovery: true})
+	engine.Use(RequestID())
+	engine.GET("/panic", func(ctx *Context) error {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set(constants.TraceIdKey, "req-3")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "boom")
+
+	entries := logs.FilterMessage("recovery from panic").All()
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "boom", fields[constants.LogErrorKey])
+	assert.Equal(t, "req-3", fields[constants.TraceIdKey])
+	assert.Contains(t, fields[constants.LogRequestKey], "GET /panic HTTP/1.1")
+	assert.Contains(t, fields[constants.LogRequestKey], "Authorization: *")
+	assert.NotContains(t, fields[constants.LogRequestKey], "secret-token")
+	assert.Contains(t, fields[constants.LogStacktraceKey], "recovery_test.go")
+}
+
+// TestRecoveryTimeoutStack 测试超时中间件转交的 panic 保留处理程序的堆栈
+func TestRecoveryTimeoutStack(t *testing.T) {
+	engine, logs := newRecoveryEngine(Config{Recovery: true})
+	engine.Use(Timeout(TimeoutConfig{Timeout: time.Second}))
+	engine.GET("/panic", func(ctx *Context) error {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	entries := logs.FilterMessage("recovery from panic").All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "boom", entries[0].ContextMap()[constants.LogErrorKey])
+		assert.Contains(t, entries[0].ContextMap()[constants.LogStacktraceKey], "recovery_test.go")
+	}
+}
+
+// TestRecoveryBrokenPipe 测试客户端断开连接时不写出响应
+func TestRecoveryBrokenPipe(t *testing.T) {
+	engine, logs := newRecoveryEngine(Config{Recovery: true})
+	engine.GET("/download", func(ctx *Context) error {
+		panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/download", nil))
+	assert.False(t, recorder.Flushed)
+	assert.Empty(t, recorder.Body.String())
+	assert.Len(t, logs.FilterMessage("broken pipe error").All(), 1)
+	assert.Empty(t, logs.FilterMessage("recovery from panic").All())
+
+	assert.True(t, isBrokenPipe(syscall.ECONNRESET))
+	assert.False(t, isBrokenPipe("broken pipe"))
+}
+
+// TestRecoveryHandler 测试自定义恢复处理器与 http.ErrAbortHandler
+func TestRecoveryHandler(t *testing.T) {
+	var recovered any
+	engine, _ := newRecoveryEngine(Config{RecoveryHandler: func(ctx *Context, err any) {
+		recovered = err
+		ctx.WriteString(http.StatusTeapot, "稍后重试")
+	}})
+	engine.GET("/panic", func(ctx *Context) error {
+		panic("boom")
+	})
+	engine.GET("/abort", func(ctx *Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+	assert.Equal(t, "稍后重试", recorder.Body.String())
+	assert.Equal(t, "boom", recovered)
+
+	// ErrAbortHandler 交给 net/http 中止连接
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+}
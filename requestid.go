@@ -0,0 +1,172 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 19:20:48
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 19:20:48
+ * @FilePath: \gosh\requestid.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// 常量定义
+const (
+	maxRequestIDLength = 128                                // 接受的外部请求 ID 最大长度
+	crockfordBase32    = "0123456789ABCDEFGHJKMNPQRSTVWXYZ" // ULID 使用的 Crockford Base32 字母表
+)
+
+// RequestIDFormat 请求 ID 格式
+type RequestIDFormat int
+
+// 请求 ID 格式常量
+const (
+	RequestIDUUIDv7 RequestIDFormat = iota // UUIDv7，按时间有序，例如 0192a4c2-7b1e-7c3a-9f12-3b4c5d6e7f80
+	RequestIDULID                          // ULID，按时间有序，例如 01JAJ4R5YE8Z3N6W2QK9V7XHTB
+)
+
+// requestIDContextKey 请求 ID 在 context.Context 中的键
+type requestIDContextKey struct{}
+
+// RequestIDConfig 请求 ID 中间件配置
+type RequestIDConfig struct {
+	Header         string          // 读取与回写请求 ID 的请求头(默认X-Trace-Id)
+	Format         RequestIDFormat // 生成的请求 ID 格式(默认UUIDv7)
+	Generator      func() string   // 自定义生成器，设置后忽略 Format
+	IgnoreIncoming bool            // 是否忽略客户端传入的请求 ID，总是重新生成
+}
+
+// RequestID 返回请求 ID 中间件
+// 请求头中带有合法的请求 ID 时沿用，否则生成新的 ID；ID 会写入响应头、
+// 通过 Context.RequestID 与 RequestIDFromContext 读取，并自动出现在 Context.Logger 的日志与 JSON 响应中
+func RequestID(config ...RequestIDConfig) HandlerFunc {
+	var cfg RequestIDConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = constants.TraceIdKey
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = NewUUIDv7
+		if cfg.Format == RequestIDULID {
+			cfg.Generator = NewULID
+		}
+	}
+
+	return func(ctx *Context) error {
+		id := ""
+		if !cfg.IgnoreIncoming {
+			id = ctx.Header(cfg.Header)
+		}
+		if !validRequestID(id) {
+			id = cfg.Generator()
+		}
+		ctx.SetRequestID(id)
+		ctx.SetHeader(cfg.Header, id)
+		return nil
+	}
+}
+
+// RequestID 返回当前请求的 ID，没有使用 RequestID 中间件时为空
+func (ctx *Context) RequestID() string {
+	return ctx.requestID
+}
+
+// SetRequestID 设置当前请求的 ID，同时写入请求的 context.Context 供下游使用
+func (ctx *Context) SetRequestID(id string) {
+	ctx.requestID = id
+	ctx.logger = nil
+	ctx.SetContextValue(requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext 从 context.Context 中读取请求 ID，可用于只拿到 Request.Context() 的下游代码
+func RequestIDFromContext(c context.Context) string {
+	id, _ := c.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// Logger 返回绑定了当前请求 ID 的日志记录器，每一行日志都带有请求 ID
+// 没有配置 Config.Zap 时返回丢弃所有日志的记录器
+func (ctx *Context) Logger() *Logger {
+	if ctx.logger == nil {
+		var base *Logger
+		if ctx.Engine != nil {
+			base = ctx.Engine.Config.Zap
+		}
+		ctx.logger = base.WithRequestID(ctx.requestID)
+	}
+	return ctx.logger
+}
+
+// logPrintln 输出与当前请求相关的标准库日志，存在请求 ID 时附加在行首
+func (ctx *Context) logPrintln(v ...any) {
+	if ctx.requestID == "" {
+		log.Println(v...)
+		return
+	}
+	log.Println(append([]any{"[" + ctx.requestID + "]"}, v...)...)
+}
+
+// validRequestID 校验外部传入的请求 ID，只接受长度合适的可见 ASCII 字符，防止日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= 0x20 || id[i] >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUIDv7 生成 RFC 9562 定义的 UUIDv7：48 位毫秒时间戳 + 74 位随机数
+func NewUUIDv7() string {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err)
+	}
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	b[6] = b[6]&0x0f | 0x70 // 版本 7
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 变体
+
+	s := hex.EncodeToString(b[:])
+	return fmt.Sprintf("%s-%s-%s-%s-%s", s[0:8], s[8:12], s[12:16], s[16:20], s[20:32])
+}
+
+// NewULID 生成 ULID：48 位毫秒时间戳 + 80 位随机数，使用 Crockford Base32 编码为 26 个字符
+func NewULID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err)
+	}
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+
+	// 128 位按 5 位一组编码，首字符只使用 3 位
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
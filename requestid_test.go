@@ -0,0 +1,121 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 19:36:10
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 19:36:10
+ * @FilePath: \gosh\requestid_test.go
+ * @Description: 测试请求 ID 功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var (
+	uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+// TestRequestIDGenerate 测试生成、沿用与拒绝非法的请求 ID
+func TestRequestIDGenerate(t *testing.T) {
+	var seen string
+	engine := NewEngine()
+	engine.Use(RequestID())
+	engine.GET("/", func(ctx *Context) error {
+		seen = ctx.RequestID()
+		assert.Equal(t, seen, RequestIDFromContext(ctx.Request.Context()))
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Regexp(t, uuidV7Pattern, seen)
+	assert.Equal(t, seen, recorder.Header().Get(constants.TraceIdKey))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(constants.TraceIdKey, "upstream-id-1")
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, "upstream-id-1", seen)
+	assert.Equal(t, "upstream-id-1", recorder.Header().Get(constants.TraceIdKey))
+
+	// 带空白或过长的外部 ID 会被替换，防止日志注入
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(constants.TraceIdKey, "forged id\tadmin")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Regexp(t, uuidV7Pattern, seen)
+}
+
+// TestRequestIDConfig 测试自定义请求头、ULID 格式与忽略外部 ID
+func TestRequestIDConfig(t *testing.T) {
+	var seen string
+	engine := NewEngine()
+	engine.Use(RequestID(RequestIDConfig{Header: "X-Request-Id", Format: RequestIDULID, IgnoreIncoming: true}))
+	engine.GET("/", func(ctx *Context) error {
+		seen = ctx.RequestID()
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "upstream")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Regexp(t, ulidPattern, seen)
+	assert.Equal(t, seen, recorder.Header().Get("X-Request-Id"))
+}
+
+// TestRequestIDOrdering 测试生成的 ID 按时间有序
+func TestRequestIDOrdering(t *testing.T) {
+	firstUUID, firstULID := NewUUIDv7(), NewULID()
+	time.Sleep(2 * time.Millisecond)
+	assert.Less(t, firstUUID, NewUUIDv7())
+	assert.Less(t, firstULID, NewULID())
+	assert.NotEqual(t, NewULID(), NewULID())
+}
+
+// TestRequestIDLoggerAndEnvelope 测试日志与 JSON 响应自动带上请求 ID
+func TestRequestIDLoggerAndEnvelope(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	base := nopLogger()
+	base.Logger = zap.New(core)
+
+	engine := NewEngine(Config{Zap: base})
+	engine.Use(RequestID())
+	engine.GET("/", func(ctx *Context) error {
+		ctx.Logger().Info("处理请求")
+		ctx.Logger().LogError("处理失败", "boom", nil, false)
+		return SendJSONResponse(ctx, &ResponseOption{Data: "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(constants.TraceIdKey, "req-42")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		for _, entry := range entries {
+			assert.Equal(t, "req-42", entry.ContextMap()[constants.TraceIdKey])
+		}
+	}
+
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, "req-42", resp["request_id"])
+
+	// 没有配置日志时返回可用的空记录器
+	ctx := &Context{Engine: NewEngine()}
+	assert.NotPanics(t, func() { ctx.Logger().Info("丢弃") })
+}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2023-11-16 00:50:58
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-17 12:16:02
+ * @LastEditTime: 2026-10-19 06:11:54
  * @FilePath: \gosh\response.go
  * @Description:
  *
@@ -22,6 +22,9 @@ type ResponseOption struct {
 	SceneCode SceneCode
 	HttpCode  StatusCode
 	Message   string
+	Language  string
+	Meta      map[string]any
+	Paging    *Pagination
 }
 
 // convertToSceneCode 辅助函数用于将输入值转换为 SceneCode 类型
@@ -68,6 +71,7 @@ func NewResponseOption(data interface{}, options ...interface{}) *ResponseOption
 }
 
 // Merge 用于处理 ResponseOption 实例的属性值
+// 没有设置 Message 时依次使用业务状态码与 HTTP 状态码在 Language 下的消息，见 DefaultCatalog
 func (o *ResponseOption) Merge() *ResponseOption {
 	// 将 o.Code 的值根据条件进行转换
 	o.SceneCode = convertToSceneCode(ternary(o.SceneCode == 0, Success, o.SceneCode))
@@ -77,10 +81,10 @@ func (o *ResponseOption) Merge() *ResponseOption {
 
 	// 根据条件设置消息内容
 	if o.Message == "" {
-		o.Message = GetSceneCodeText(o.SceneCode)
+		o.Message = GetLocalizedSceneCodeText(o.Language, o.SceneCode)
 	}
 	if o.Message == "" {
-		o.Message = GetStatusCodeText(o.HttpCode)
+		o.Message = GetLocalizedStatusCodeText(o.Language, o.HttpCode)
 	}
 
 	return o
@@ -94,22 +98,19 @@ func ternary(condition bool, trueVal, falseVal interface{}) interface{} {
 	return falseVal
 }
 
-// SendJSONResponse 生成 JSON 格式的响应
+// SendJSONResponse 生成 JSON 格式的响应，字段由 Config.Envelope 决定
 func SendJSONResponse(c *Context, respOption *ResponseOption) error {
 	if respOption == nil {
 		respOption = &ResponseOption{}
 	}
-	respOption.Merge()
-
-	// 创建一个map来存储不包含HttpStatusCode和Language的字段
-	cleanedResp := map[string]interface{}{
-		"data":    respOption.Data,
-		"code":    respOption.SceneCode,
-		"message": respOption.Message,
+	if respOption.Language == "" {
+		respOption.Language = c.Language()
 	}
+	respOption.Merge()
 
-	c.WriteJSONResponse(int(respOption.HttpCode), cleanedResp)
-	return nil
+	// 按引擎配置的响应信封输出，HttpCode 与 Language 不出现在响应体中
+	envelope := c.envelopeConfig()
+	return c.WriteJSONResponse(envelope.status(respOption), envelope.build(c, respOption))
 }
 
 // Gen400xResponse 生成 HTTP 400x 错误响应
@@ -119,7 +120,7 @@ func Gen400xResponse(ctx *Context, respOption *ResponseOption) {
 	}
 	respOption.SceneCode = BadRequest
 	respOption.HttpCode = StatusBadRequest
-	SendJSONResponse(ctx, respOption)
+	SendErrorResponse(ctx, respOption)
 }
 
 // Gen500xResponse 生成 HTTP 500 错误响应
@@ -129,15 +130,15 @@ func Gen500xResponse(ctx *Context, respOption *ResponseOption) {
 	}
 	respOption.SceneCode = Fail
 	respOption.HttpCode = StatusInternalServerError
-	SendJSONResponse(ctx, respOption)
+	SendErrorResponse(ctx, respOption)
 }
 
-// ValidatorError 处理字段校验异常
+// ValidatorError 处理字段校验异常，校验错误按当前请求的语言翻译，见 Context.Translator
 func ValidatorError(ctx *Context, err error) {
 	if errs, ok := err.(validator.ValidationErrors); ok {
 		Gen400xResponse(ctx, &ResponseOption{
 			SceneCode: ValidateError,
-			Data:      RemoveTopStruct(errs.Translate(ctx.Engine.Config.Trans)),
+			Data:      RemoveTopStruct(errs.Translate(ctx.Translator())),
 		})
 		return
 	}
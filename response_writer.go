@@ -0,0 +1,201 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 11:10:25
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 11:10:25
+ * @FilePath: \gosh\response_writer.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// ResponseWriter 在 http.ResponseWriter 的基础上记录状态码、写入字节数和写入状态
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Hijacker
+	http.Flusher
+	http.Pusher
+	io.ReaderFrom
+	io.StringWriter
+
+	Status() int                    // 返回已写出（或即将写出）的状态码
+	Size() int                      // 返回已写入的响应体字节数
+	Written() bool                  // 返回响应头是否已经发送
+	Hijacked() bool                 // 返回底层连接是否已被劫持
+	WriteHeaderNow()                // 立即发送响应头
+	Before(fn func(ResponseWriter)) // 注册在发送响应头之前执行的回调，按注册的逆序执行
+	Unwrap() http.ResponseWriter    // 返回原始的 http.ResponseWriter，供 http.ResponseController 使用
+}
+
+// responseWriter ResponseWriter 的默认实现，随 Context 一起复用
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+	hijacked    bool
+	beforeFuncs []func(ResponseWriter)
+}
+
+// 确保 responseWriter 实现了 ResponseWriter
+var _ ResponseWriter = (*responseWriter)(nil)
+
+// reset 绑定新的底层写入器并清空状态
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = http.StatusOK
+	w.size = 0
+	w.wroteHeader = false
+	w.hijacked = false
+	w.beforeFuncs = w.beforeFuncs[:0]
+}
+
+// Status 返回状态码
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size 返回已写入的响应体字节数
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Written 返回响应头是否已经发送
+func (w *responseWriter) Written() bool {
+	return w.wroteHeader
+}
+
+// Hijacked 返回底层连接是否已被劫持
+func (w *responseWriter) Hijacked() bool {
+	return w.hijacked
+}
+
+// Before 注册在发送响应头之前执行的回调，可用于最后时刻修改响应头（如写入 Cookie）
+func (w *responseWriter) Before(fn func(ResponseWriter)) {
+	w.beforeFuncs = append(w.beforeFuncs, fn)
+}
+
+// WriteHeader 发送响应头，重复调用只会记录警告而不会覆盖已发送的状态码
+func (w *responseWriter) WriteHeader(code int) {
+	if w.hijacked {
+		log.Printf("[WARNING] 连接已被劫持，忽略状态码 %d", code)
+		return
+	}
+	if w.wroteHeader {
+		log.Printf("[WARNING] 响应头已经发送，无法将状态码 %d 修改为 %d", w.status, code)
+		return
+	}
+	w.status = code
+	w.WriteHeaderNow()
+}
+
+// WriteHeaderNow 立即发送响应头
+func (w *responseWriter) WriteHeaderNow() {
+	if w.wroteHeader || w.hijacked {
+		return
+	}
+	// 先取出回调再执行，避免回调内写入响应时重复触发
+	funcs := w.beforeFuncs
+	w.beforeFuncs = nil
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i](w)
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+	w.beforeFuncs = funcs[:0]
+}
+
+// Write 写入响应体，未发送响应头时默认发送 200
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+// WriteString 写入字符串响应体
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	n, err := io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return n, err
+}
+
+// ReadFrom 从 Reader 复制响应体，底层支持时可使用 sendfile 等零拷贝方式
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.WriteHeaderNow()
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+	}
+	w.size += int(n)
+	return n, err
+}
+
+// Flush 发送响应头并刷新缓冲数据
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 劫持底层连接
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errorsx.ErrHijackNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Push 执行 HTTP/2 服务端推送
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap 返回原始的 http.ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Writer 返回当前请求的 ResponseWriter，
+// 如果 ResponseWriter 字段被替换为普通的 http.ResponseWriter，会自动重新包装
+func (ctx *Context) Writer() ResponseWriter {
+	if rw, ok := ctx.ResponseWriter.(ResponseWriter); ok {
+		return rw
+	}
+	rw := &responseWriter{}
+	rw.reset(ctx.ResponseWriter)
+	ctx.ResponseWriter = rw
+	return rw
+}
+
+// isHijacked 判断底层连接是否已被劫持
+func (ctx *Context) isHijacked() bool {
+	if rw, ok := ctx.ResponseWriter.(ResponseWriter); ok {
+		return rw.Hijacked()
+	}
+	return false
+}
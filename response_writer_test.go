@@ -0,0 +1,116 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 11:36:40
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 11:36:40
+ * @FilePath: \gosh\response_writer_test.go
+ * @Description: 测试 ResponseWriter 功能
+ */
+package gosh
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResponseWriterTracksStatusAndSize 测试状态码和写入字节数的记录
+func TestResponseWriterTracksStatusAndSize(t *testing.T) {
+	var status, size int
+	engine := NewEngine(Config{
+		AfterHandler: func(ctx *Context) {
+			status = ctx.Status
+			size = ctx.Writer().Size()
+		},
+	})
+	engine.GET("/json", func(ctx *Context) error {
+		return ctx.WriteJSONResponse(http.StatusCreated, H{"ok": true})
+	})
+	engine.GET("/raw", func(ctx *Context) error {
+		ctx.ResponseWriter.WriteHeader(http.StatusAccepted)
+		_, err := ctx.ResponseWriter.Write([]byte("accepted"))
+		return err
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/json", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, len(`{"ok":true}`), size)
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/raw", nil))
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, len("accepted"), size)
+}
+
+// TestResponseWriterIgnoresDoubleWriteHeader 测试重复写入响应头
+func TestResponseWriterIgnoresDoubleWriteHeader(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := &responseWriter{}
+	writer.reset(recorder)
+
+	assert.False(t, writer.Written())
+	writer.WriteHeader(http.StatusNotFound)
+	writer.WriteHeader(http.StatusOK)
+	assert.True(t, writer.Written())
+	assert.Equal(t, http.StatusNotFound, writer.Status())
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestResponseWriterBefore 测试发送响应头前的回调
+func TestResponseWriterBefore(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/", func(ctx *Context) error {
+		ctx.Writer().Before(func(w ResponseWriter) {
+			w.Header().Set("X-First", "1")
+		})
+		ctx.Writer().Before(func(w ResponseWriter) {
+			w.Header().Set("X-Order", w.Header().Get("X-First")+"-2")
+		})
+		return ctx.WriteString(http.StatusOK, "ok")
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "1", recorder.Header().Get("X-First"))
+	assert.Equal(t, "-2", recorder.Header().Get("X-Order")) // 逆序执行
+}
+
+// TestResponseWriterReadFromAndFlush 测试 ReadFrom 与 Flush
+func TestResponseWriterReadFromAndFlush(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := &responseWriter{}
+	writer.reset(recorder)
+
+	n, err := writer.ReadFrom(strings.NewReader("streamed"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), n)
+	assert.Equal(t, 8, writer.Size())
+
+	writer.Flush()
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, "streamed", recorder.Body.String())
+
+	assert.ErrorIs(t, writer.Push("/style.css", nil), http.ErrNotSupported)
+	_, _, err = writer.Hijack()
+	assert.Error(t, err)
+	assert.False(t, writer.Hijacked())
+}
+
+// TestResponseWriterErrorAfterWrite 测试响应已发送后出错不再写入错误响应
+func TestResponseWriterErrorAfterWrite(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/", func(ctx *Context) error {
+		ctx.WriteString(http.StatusOK, "partial")
+		return assert.AnError
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "partial", recorder.Body.String())
+}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 08:59:07
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-17 12:05:15
+ * @LastEditTime: 2026-10-19 05:49:03
  * @FilePath: \gosh\router_group.go
  * @Description:
  *
@@ -24,13 +24,18 @@ type RouterGroup struct {
 	Engine   *Engine       // 引擎实例
 	root     bool          // 是否为根路由组
 	noRoute  HandlersChain // 没有匹配路由时的处理程序
+	meta     RouteMeta     // 注册到路由上的元数据
 }
 
+// RouteMeta 路由元数据，中间件可以通过 Context.RouteMeta 读取，实现按路由配置
+type RouteMeta map[string]any
+
 // RouteInfo 表示请求路由的规范，包括请求方法、路径及其处理函数。
 type RouteInfo struct {
 	Method  string        // 请求方法，例如 GET、POST 等
 	Path    string        // 请求路径
 	Handler HandlersChain // 实际的处理函数
+	Meta    RouteMeta     // 路由元数据
 }
 
 // NoRoute 注册没有匹配路由时的处理程序
@@ -49,7 +54,35 @@ func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *R
 		handlers: group.combineHandlers(handlers),
 		basePath: group.calculateAbsolutePath(relativePath),
 		Engine:   group.Engine,
+		meta:     group.meta.with(nil),
+	}
+}
+
+// WithMeta 返回附带元数据的路由组，通过它注册的路由（包括子路由组）都会带上这些元数据
+// 例如 api.WithMeta(TimeoutMetaKey, 2*time.Second).GET("/report", handler)
+func (group *RouterGroup) WithMeta(key string, value any) *RouterGroup {
+	return &RouterGroup{
+		handlers: group.combineHandlers(nil),
+		basePath: group.basePath,
+		Engine:   group.Engine,
+		noRoute:  group.noRoute,
+		meta:     group.meta.with(RouteMeta{key: value}),
+	}
+}
+
+// with 返回合并了 other 的新元数据，不修改原有元数据
+func (meta RouteMeta) with(other RouteMeta) RouteMeta {
+	if len(meta) == 0 && len(other) == 0 {
+		return nil
+	}
+	merged := make(RouteMeta, len(meta)+len(other))
+	for key, value := range meta {
+		merged[key] = value
+	}
+	for key, value := range other {
+		merged[key] = value
 	}
+	return merged
 }
 
 // handle 处理路由注册
@@ -64,6 +97,7 @@ func (group *RouterGroup) handle(httpMethod, relativePath string, handlers Handl
 	// 合并处理程序链
 	handlers = group.combineHandlers(handlers)
 	group.Engine.addRoute(httpMethod, absolutePath, handlers)
+	group.Engine.setRouteMeta(httpMethod, absolutePath, group.meta)
 	return nil
 }
 
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-16 17:30:00
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-16 18:16:30
+ * @LastEditTime: 2026-10-19 05:35:11
  * @FilePath: \gosh\router_group_test.go
  * @Description: 测试 RouterGroup 功能
  */
@@ -106,3 +106,49 @@ func TestRouterGroup_ConflictHandling(t *testing.T) {
 		group.GET("/test", handler2) // 这应该导致 panic
 	}, "Expected panic when registering the same route")
 }
+
+// 测试共享前缀的路由注册
+func TestRouterGroupSharedPrefix(t *testing.T) {
+	engine := NewEngine()
+	paths := []string{"/anonymous", "/login", "/me", "/logout", "/log", "/users/:id", "/users/:id/posts"}
+	for _, path := range paths {
+		path := path
+		engine.GET(path, func(c *Context) error {
+			return c.WriteString(http.StatusOK, path)
+		})
+	}
+
+	for _, path := range []string{"/anonymous", "/login", "/me", "/logout", "/log"} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, path, recorder.Body.String())
+	}
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1/posts", nil))
+	assert.Equal(t, "/users/:id/posts", recorder.Body.String())
+}
+
+// 测试中间件在后续处理程序执行完之后继续处理
+func TestRouterGroupMiddlewareAfterNext(t *testing.T) {
+	var order []string
+	engine := NewEngine()
+	engine.Use(func(c *Context) error {
+		order = append(order, "before")
+		c.Next()
+		order = append(order, "after")
+		return nil
+	})
+	engine.GET("/", func(c *Context) error {
+		order = append(order, "handler")
+		return assert.AnError
+	}, func(c *Context) error {
+		order = append(order, "unreachable")
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []string{"before", "handler", "after"}, order)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-15 23:26:10
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-16 16:41:09
+ * @LastEditTime: 2026-10-19 05:45:53
  * @FilePath: \gosh\scene_code.go
  * @Description:
  *
@@ -23,22 +23,25 @@ type SceneCode int
 
 // 自定义状态码
 const (
-	Success       = 200  // 成功
-	BadRequest    = 400  // 错误请求
-	Fail          = 500  // 失败
-	ServerError   = 1000 // 服务器错误
-	ValidateError = 1001 // 参数校验错误
-	Deadline      = 1002 // 服务调用超时
-	CreateError   = 1003 // 服务器写入失败
-	FindError     = 1004 // 服务器查询失败
-	WithoutServer = 1005 // 服务未启用
-	AuthError     = 1006 // 权限错误
-	DeleteError   = 1007 // 服务器删除失败
-	EmptyFile     = 1008 // 文件为空
-	RateLimit     = 1009 // 访问限流
-	Unauthorized  = 1010 // 认证失败
-	WithoutLogin  = 1011 // 用户未登录
-	DisableAuth   = 1012 // 禁止访问
+	Success             = 200  // 成功
+	BadRequest          = 400  // 错误请求
+	Fail                = 500  // 失败
+	ServerError         = 1000 // 服务器错误
+	ValidateError       = 1001 // 参数校验错误
+	Deadline            = 1002 // 服务调用超时
+	CreateError         = 1003 // 服务器写入失败
+	FindError           = 1004 // 服务器查询失败
+	WithoutServer       = 1005 // 服务未启用
+	AuthError           = 1006 // 权限错误
+	DeleteError         = 1007 // 服务器删除失败
+	EmptyFile           = 1008 // 文件为空
+	RateLimit           = 1009 // 访问限流
+	Unauthorized        = 1010 // 认证失败
+	WithoutLogin        = 1011 // 用户未登录
+	DisableAuth         = 1012 // 禁止访问
+	BodyTooLarge        = 1013 // 请求体过大
+	URLSignatureInvalid = 1014 // 链接签名无效
+	URLExpired          = 1015 // 链接已过期
 )
 
 // sceneCodeMsgMap 用于存储状态码和消息的映射关系
@@ -47,22 +50,25 @@ var sceneCodeMsgMap = struct {
 	mapping map[SceneCode]string
 }{
 	mapping: map[SceneCode]string{
-		Success:       "Success",
-		BadRequest:    "Bad Request",
-		Fail:          "Fail",
-		ServerError:   "Internal Server Error",
-		ValidateError: "Validation Error",
-		Deadline:      "Deadline Exceeded",
-		CreateError:   "Failed to Create",
-		FindError:     "Failed to Find",
-		WithoutServer: "Service Unavailable",
-		AuthError:     "Authorization Error",
-		DeleteError:   "Failed to Delete",
-		EmptyFile:     "Empty File",
-		RateLimit:     "Rate Limit Exceeded",
-		Unauthorized:  "Unauthorized",
-		WithoutLogin:  "User Not Logged In",
-		DisableAuth:   "User Authentication Disabled",
+		Success:             "Success",
+		BadRequest:          "Bad Request",
+		Fail:                "Fail",
+		ServerError:         "Internal Server Error",
+		ValidateError:       "Validation Error",
+		Deadline:            "Deadline Exceeded",
+		CreateError:         "Failed to Create",
+		FindError:           "Failed to Find",
+		WithoutServer:       "Service Unavailable",
+		AuthError:           "Authorization Error",
+		DeleteError:         "Failed to Delete",
+		EmptyFile:           "Empty File",
+		RateLimit:           "Rate Limit Exceeded",
+		Unauthorized:        "Unauthorized",
+		WithoutLogin:        "User Not Logged In",
+		DisableAuth:         "User Authentication Disabled",
+		BodyTooLarge:        "Request Body Too Large",
+		URLSignatureInvalid: "Invalid URL Signature",
+		URLExpired:          "URL Expired",
 	},
 }
 
@@ -0,0 +1,319 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 14:10:26
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:41:18
+ * @FilePath: \gosh\session.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// 常量定义
+const (
+	defaultSessionCookieName      = "gosh_session"   // 默认的会话 Cookie 名称
+	defaultSessionIdleTimeout     = 30 * time.Minute // 默认空闲超时
+	defaultSessionAbsoluteTimeout = 24 * time.Hour   // 默认绝对超时
+	sessionIDBytes                = 32               // 会话 ID 的随机字节数
+)
+
+// SessionConfig 会话中间件配置
+type SessionConfig struct {
+	Store           SessionStore  // 会话存储，默认为内存存储
+	CookieName      string        // 保存会话 ID 的 Cookie 名称(默认gosh_session)
+	Cookie          CookieOptions // 会话 Cookie 属性，其中的 HttpOnly 由 DisableHttpOnly 决定
+	DisableHttpOnly bool          // 允许脚本读取会话 Cookie，默认总是开启 HttpOnly
+	IdleTimeout     time.Duration // 空闲超时，超过该时间未访问的会话失效(默认30分钟)
+	AbsoluteTimeout time.Duration // 绝对超时，从创建开始超过该时间的会话失效(默认24小时)
+}
+
+// SessionData 会话中保存的数据，文件存储和 Cookie 存储会将其编码为 JSON
+type SessionData struct {
+	Values     map[string]any   `json:"values,omitempty"`  // 会话值
+	Flashes    map[string][]any `json:"flashes,omitempty"` // 闪存消息，读取一次后删除
+	CreatedAt  time.Time        `json:"created_at"`        // 创建时间
+	AccessedAt time.Time        `json:"accessed_at"`       // 最后访问时间
+}
+
+// Session 当前请求的会话
+// 使用 JSON 编码的存储中，数字类型的值读取后为 float64
+type Session struct {
+	mu        sync.Mutex
+	id        string
+	oldID     string // RenewID 之前的会话 ID，提交时从存储中删除
+	data      *SessionData
+	isNew     bool
+	modified  bool
+	destroyed bool
+}
+
+// sessionState 会话中间件在上下文中保存的状态
+// 超时中间件的处理程序 goroutine 与原请求共享该状态，字段由 mu 保护
+type sessionState struct {
+	mu        sync.Mutex
+	config    *SessionConfig
+	session   *Session
+	committed bool
+}
+
+// Sessions 返回会话中间件，处理器中通过 ctx.Session() 读写会话
+// 会话在响应头发送前保存，只有被访问过的会话才会刷新空闲超时
+func Sessions(config ...SessionConfig) HandlerFunc {
+	cfg := SessionConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemorySessionStore()
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = defaultSessionCookieName
+	}
+	cfg.Cookie.HttpOnly = !cfg.DisableHttpOnly
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaultSessionIdleTimeout
+	}
+	if cfg.AbsoluteTimeout <= 0 {
+		cfg.AbsoluteTimeout = defaultSessionAbsoluteTimeout
+	}
+
+	return func(ctx *Context) error {
+		state := &sessionState{config: &cfg}
+		ctx.sessionState = state
+		ctx.Writer().Before(func(ResponseWriter) {
+			state.commit(ctx)
+		})
+
+		ctx.Next()
+
+		// 处理器没有写出响应时也需要保存会话
+		if !ctx.Writer().Written() && !ctx.isHijacked() {
+			state.commit(ctx)
+		}
+		return nil
+	}
+}
+
+// Session 返回当前请求的会话，首次调用时从存储中加载
+// 未使用 Sessions 中间件时返回 nil；会话已经提交(例如处理程序超时后)时返回不会被保存的空会话
+func (ctx *Context) Session() *Session {
+	state := ctx.sessionState
+	if state == nil {
+		return nil
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.session != nil {
+		return state.session
+	}
+	if state.committed {
+		now := time.Now()
+		return &Session{isNew: true, data: &SessionData{Values: make(map[string]any), CreatedAt: now, AccessedAt: now}}
+	}
+	state.session = state.load(ctx)
+	return state.session
+}
+
+// load 根据请求中的 Cookie 加载会话，会话不存在或已过期时创建新会话
+func (state *sessionState) load(ctx *Context) *Session {
+	now := time.Now()
+	if id, err := ctx.Cookie(state.config.CookieName); err == nil && id != "" {
+		data, err := state.config.Store.Load(ctx, id)
+		if err != nil {
+			ctx.logPrintln("加载会话失败:", err)
+		}
+		if data != nil {
+			if !state.expired(data, now) {
+				data.AccessedAt = now
+				return &Session{id: id, data: data}
+			}
+			if err := state.config.Store.Delete(ctx, id); err != nil {
+				ctx.logPrintln("删除过期会话失败:", err)
+			}
+		}
+	}
+
+	return &Session{
+		id:    newSessionID(),
+		isNew: true,
+		data:  &SessionData{Values: make(map[string]any), CreatedAt: now, AccessedAt: now},
+	}
+}
+
+// expired 判断会话是否已超过空闲超时或绝对超时
+func (state *sessionState) expired(data *SessionData, now time.Time) bool {
+	return now.Sub(data.AccessedAt) > state.config.IdleTimeout || now.Sub(data.CreatedAt) > state.config.AbsoluteTimeout
+}
+
+// ttl 返回会话在存储中的剩余有效期
+func (state *sessionState) ttl(data *SessionData) time.Duration {
+	ttl := state.config.IdleTimeout
+	if remaining := state.config.AbsoluteTimeout - time.Since(data.CreatedAt); remaining < ttl {
+		ttl = remaining
+	}
+	return ttl
+}
+
+// commit 保存会话并写入 Cookie，只执行一次
+func (state *sessionState) commit(ctx *Context) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.committed {
+		return
+	}
+	state.committed = true
+	if state.session == nil {
+		return
+	}
+
+	session := state.session
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	store := state.config.Store
+	if session.oldID != "" {
+		if err := store.Delete(ctx, session.oldID); err != nil {
+			ctx.logPrintln("删除旧会话失败:", err)
+		}
+	}
+
+	if session.destroyed {
+		if err := store.Delete(ctx, session.id); err != nil {
+			ctx.logPrintln("删除会话失败:", err)
+		}
+		ctx.DeleteCookie(state.config.CookieName, state.config.Cookie)
+		return
+	}
+
+	// 没有任何数据的新会话不保存，避免为匿名访问创建会话
+	if session.isNew && !session.modified {
+		return
+	}
+
+	if err := store.Save(ctx, session.id, session.data, state.ttl(session.data)); err != nil {
+		ctx.logPrintln("保存会话失败:", err)
+		return
+	}
+	if session.isNew || session.oldID != "" {
+		if err := ctx.SetCookieWithOptions(state.config.CookieName, session.id, state.config.Cookie); err != nil {
+			ctx.logPrintln("写入会话 Cookie 失败:", err)
+		}
+	}
+}
+
+// newSessionID 生成 256 位随机会话 ID
+func newSessionID() string {
+	buf := make([]byte, sessionIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ID 返回会话 ID
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// IsNew 判断会话是否为本次请求新建
+func (s *Session) IsNew() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isNew
+}
+
+// Get 获取会话值
+func (s *Session) Get(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.data.Values[key]
+	return value, ok
+}
+
+// GetString 获取字符串类型的会话值
+func (s *Session) GetString(key string) string {
+	value, _ := s.Get(key)
+	str, _ := value.(string)
+	return str
+}
+
+// Set 设置会话值
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Values == nil {
+		s.data.Values = make(map[string]any)
+	}
+	s.data.Values[key] = value
+	s.modified = true
+}
+
+// Delete 删除会话值
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.Values, key)
+	s.modified = true
+}
+
+// Clear 清空会话中的全部值和闪存消息
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Values = make(map[string]any)
+	s.data.Flashes = nil
+	s.modified = true
+}
+
+// Flash 添加闪存消息，消息在下一次通过 Flashes 读取后删除
+func (s *Session) Flash(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Flashes == nil {
+		s.data.Flashes = make(map[string][]any)
+	}
+	s.data.Flashes[key] = append(s.data.Flashes[key], value)
+	s.modified = true
+}
+
+// Flashes 读取并删除闪存消息
+func (s *Session) Flashes(key string) []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flashes, ok := s.data.Flashes[key]
+	if ok {
+		delete(s.data.Flashes, key)
+		s.modified = true
+	}
+	return flashes
+}
+
+// RenewID 更换会话 ID 并保留会话数据，登录或权限变化后调用以防止会话固定攻击
+func (s *Session) RenewID() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew && s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = newSessionID()
+	s.modified = true
+}
+
+// Destroy 销毁会话，删除存储中的数据并清除 Cookie
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Values = make(map[string]any)
+	s.data.Flashes = nil
+	s.destroyed = true
+}
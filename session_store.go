@@ -0,0 +1,286 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 14:31:02
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 14:31:02
+ * @FilePath: \gosh\session_store.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 常量定义
+const (
+	defaultSessionDataCookieName = "gosh_session_data" // Cookie 存储默认使用的 Cookie 名称
+	memorySessionSweepInterval   = time.Minute         // 内存存储清理过期会话的间隔
+	sessionFileExt               = ".json"             // 文件存储的文件扩展名
+)
+
+// SessionStore 会话存储接口
+// Load 在会话不存在或已过期时返回 nil, nil；ttl 为会话在存储中的有效期
+type SessionStore interface {
+	Load(ctx *Context, id string) (*SessionData, error)
+	Save(ctx *Context, id string, data *SessionData, ttl time.Duration) error
+	Delete(ctx *Context, id string) error
+}
+
+// cloneSessionData 复制会话数据，避免存储与请求共享同一个 map
+func cloneSessionData(data *SessionData) *SessionData {
+	cp := &SessionData{CreatedAt: data.CreatedAt, AccessedAt: data.AccessedAt}
+	if data.Values != nil {
+		cp.Values = make(map[string]any, len(data.Values))
+		for k, v := range data.Values {
+			cp.Values[k] = v
+		}
+	}
+	if data.Flashes != nil {
+		cp.Flashes = make(map[string][]any, len(data.Flashes))
+		for k, v := range data.Flashes {
+			cp.Flashes[k] = append([]any(nil), v...)
+		}
+	}
+	return cp
+}
+
+// memorySessionEntry 内存存储中的会话
+type memorySessionEntry struct {
+	data      *SessionData
+	expiresAt time.Time
+}
+
+// MemorySessionStore 内存会话存储，适用于单实例部署，进程重启后会话丢失
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memorySessionEntry
+	lastSweep time.Time
+}
+
+// NewMemorySessionStore 创建内存会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]memorySessionEntry), lastSweep: time.Now()}
+}
+
+// Load 加载会话
+func (s *MemorySessionStore) Load(_ *Context, id string) (*SessionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.sessions, id)
+		return nil, nil
+	}
+	return cloneSessionData(entry.data), nil
+}
+
+// Save 保存会话，同时定期清理已过期的会话
+func (s *MemorySessionStore) Save(_ *Context, id string, data *SessionData, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = memorySessionEntry{data: cloneSessionData(data), expiresAt: now.Add(ttl)}
+
+	if now.Sub(s.lastSweep) >= memorySessionSweepInterval {
+		s.lastSweep = now
+		for key, entry := range s.sessions {
+			if now.After(entry.expiresAt) {
+				delete(s.sessions, key)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete 删除会话
+func (s *MemorySessionStore) Delete(_ *Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// Len 返回存储中的会话数量（包括尚未清理的过期会话）
+func (s *MemorySessionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// fileSessionRecord 文件存储中的会话记录
+type fileSessionRecord struct {
+	Data      *SessionData `json:"data"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// FileSessionStore 文件会话存储，每个会话保存为目录下的一个 JSON 文件
+type FileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore 创建文件会话存储，目录不存在时自动创建
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// path 返回会话文件路径，会话 ID 必须为十六进制字符串，防止路径穿越
+func (s *FileSessionStore) path(id string) (string, bool) {
+	if len(id) != sessionIDBytes*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", false
+	}
+	return filepath.Join(s.dir, id+sessionFileExt), true
+}
+
+// Load 加载会话，过期的会话文件会被删除
+func (s *FileSessionStore) Load(_ *Context, id string) (*SessionData, error) {
+	path, ok := s.path(id)
+	if !ok {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var record fileSessionRecord
+	// 无法解析或已过期的会话文件直接删除
+	if err := json.Unmarshal(content, &record); err != nil || record.Data == nil || time.Now().After(record.ExpiresAt) {
+		os.Remove(path)
+		return nil, nil
+	}
+	return record.Data, nil
+}
+
+// Save 保存会话，先写入临时文件再重命名，避免读取到写了一半的文件
+func (s *FileSessionStore) Save(_ *Context, id string, data *SessionData, ttl time.Duration) error {
+	path, ok := s.path(id)
+	if !ok {
+		return nil
+	}
+	content, err := json.Marshal(fileSessionRecord{Data: data, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, id+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete 删除会话文件
+func (s *FileSessionStore) Delete(_ *Context, id string) error {
+	path, ok := s.path(id)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Cleanup 删除目录中所有已过期的会话文件，可以由定时任务调用
+func (s *FileSessionStore) Cleanup() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), sessionFileExt) {
+			continue
+		}
+		if _, err := s.Load(nil, strings.TrimSuffix(entry.Name(), sessionFileExt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cookieSessionRecord Cookie 存储中的会话记录
+type cookieSessionRecord struct {
+	ID   string       `json:"id"`
+	Data *SessionData `json:"data"`
+}
+
+// CookieSessionStore Cookie 会话存储，会话数据以签名 Cookie 的形式保存在客户端
+// 依赖 Engine 的密钥环，数据对客户端可见但无法篡改，总大小受 4096 字节限制
+type CookieSessionStore struct {
+	name    string
+	options CookieOptions
+}
+
+// NewCookieSessionStore 创建 Cookie 会话存储，name 为空时使用 gosh_session_data
+func NewCookieSessionStore(name string, options ...CookieOptions) *CookieSessionStore {
+	if name == "" {
+		name = defaultSessionDataCookieName
+	}
+	store := &CookieSessionStore{name: name, options: CookieOptions{HttpOnly: true}}
+	if len(options) > 0 {
+		store.options = options[0]
+	}
+	return store
+}
+
+// Load 从签名 Cookie 中加载会话，会话 ID 不匹配时视为不存在
+func (s *CookieSessionStore) Load(ctx *Context, id string) (*SessionData, error) {
+	value, err := ctx.SignedCookie(s.name)
+	if err != nil {
+		return nil, nil // 没有 Cookie、签名无效或已过期都视为会话不存在
+	}
+
+	var record cookieSessionRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil || record.ID != id || record.Data == nil {
+		return nil, nil
+	}
+	return record.Data, nil
+}
+
+// Save 将会话写入签名 Cookie，Cookie 的有效期与会话一致
+func (s *CookieSessionStore) Save(ctx *Context, id string, data *SessionData, ttl time.Duration) error {
+	content, err := json.Marshal(cookieSessionRecord{ID: id, Data: data})
+	if err != nil {
+		return err
+	}
+	options := s.options
+	options.MaxAge = int(ttl / time.Second)
+	return ctx.SetSignedCookie(s.name, string(content), options)
+}
+
+// Delete 删除会话 Cookie
+func (s *CookieSessionStore) Delete(ctx *Context, _ string) error {
+	ctx.DeleteCookie(s.name, s.options)
+	return nil
+}
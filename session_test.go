@@ -0,0 +1,210 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 14:52:44
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:41:18
+ * @FilePath: \gosh\session_test.go
+ * @Description: 测试会话中间件与会话存储功能
+ */
+package gosh
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionClient 记录 Cookie 的测试客户端
+type sessionClient struct {
+	engine  *Engine
+	cookies map[string]*http.Cookie
+}
+
+// do 发送请求并保存响应中的 Cookie
+func (c *sessionClient) do(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range c.cookies {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	recorder := httptest.NewRecorder()
+	c.engine.ServeHTTP(recorder, req)
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(c.cookies, cookie.Name)
+			continue
+		}
+		c.cookies[cookie.Name] = cookie
+	}
+	return recorder
+}
+
+// newSessionEngine 注册测试用的会话路由
+func newSessionEngine(config SessionConfig, engineConfig ...Config) *sessionClient {
+	engine := NewEngine(engineConfig...)
+	engine.Use(Sessions(config))
+	engine.GET("/anonymous", func(ctx *Context) error {
+		return ctx.WriteString(http.StatusOK, ctx.Session().GetString("user"))
+	})
+	engine.GET("/login", func(ctx *Context) error {
+		session := ctx.Session()
+		session.RenewID()
+		session.Set("user", "kamalyes")
+		session.Flash("notice", "welcome")
+		return ctx.WriteString(http.StatusOK, "ok")
+	})
+	engine.GET("/me", func(ctx *Context) error {
+		session := ctx.Session()
+		flashes := session.Flashes("notice")
+		if len(flashes) > 0 {
+			ctx.ResponseWriter.Header().Set("X-Flash", flashes[0].(string))
+		}
+		return ctx.WriteString(http.StatusOK, session.GetString("user"))
+	})
+	engine.GET("/logout", func(ctx *Context) error {
+		ctx.Session().Destroy()
+		return nil
+	})
+	return &sessionClient{engine: engine, cookies: make(map[string]*http.Cookie)}
+}
+
+// runSessionFlow 测试登录、闪存消息和注销流程
+func runSessionFlow(t *testing.T, client *sessionClient) {
+	recorder := client.do("/anonymous")
+	assert.Empty(t, recorder.Result().Cookies()) // 匿名访问不创建会话
+
+	client.do("/login")
+	assert.Contains(t, client.cookies, defaultSessionCookieName)
+	firstID := client.cookies[defaultSessionCookieName].Value
+
+	recorder = client.do("/me")
+	assert.Equal(t, "kamalyes", recorder.Body.String())
+	assert.Equal(t, "welcome", recorder.Header().Get("X-Flash"))
+
+	recorder = client.do("/me")
+	assert.Equal(t, "kamalyes", recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get("X-Flash")) // 闪存消息只能读取一次
+
+	// 再次登录会更换会话 ID，旧 ID 失效
+	client.do("/login")
+	secondID := client.cookies[defaultSessionCookieName].Value
+	assert.NotEqual(t, firstID, secondID)
+
+	client.do("/logout")
+	assert.NotContains(t, client.cookies, defaultSessionCookieName)
+	recorder = client.do("/me")
+	assert.Empty(t, recorder.Body.String())
+}
+
+// TestSessionsMemoryStore 测试内存会话存储
+func TestSessionsMemoryStore(t *testing.T) {
+	store := NewMemorySessionStore()
+	client := newSessionEngine(SessionConfig{Store: store})
+	runSessionFlow(t, client)
+	assert.Equal(t, 0, store.Len())
+
+	// 使用旧会话 ID 无法访问
+	client.do("/login")
+	oldID := client.cookies[defaultSessionCookieName].Value
+	client.do("/login")
+	client.cookies[defaultSessionCookieName] = &http.Cookie{Name: defaultSessionCookieName, Value: oldID}
+	assert.Empty(t, client.do("/me").Body.String())
+}
+
+// TestSessionsCookieAttributes 测试设置其他 Cookie 属性时仍默认开启 HttpOnly
+func TestSessionsCookieAttributes(t *testing.T) {
+	client := newSessionEngine(SessionConfig{Cookie: CookieOptions{Secure: true, Path: "/app"}})
+	client.do("/login")
+	cookie := client.cookies[defaultSessionCookieName]
+	if assert.NotNil(t, cookie) {
+		assert.True(t, cookie.HttpOnly)
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, "/app", cookie.Path)
+	}
+
+	client = newSessionEngine(SessionConfig{DisableHttpOnly: true})
+	client.do("/login")
+	if cookie = client.cookies[defaultSessionCookieName]; assert.NotNil(t, cookie) {
+		assert.False(t, cookie.HttpOnly)
+	}
+}
+
+// TestSessionsFileStore 测试文件会话存储
+func TestSessionsFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileSessionStore(dir)
+	assert.NoError(t, err)
+	client := newSessionEngine(SessionConfig{Store: store})
+	runSessionFlow(t, client)
+
+	client.do("/login")
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1)
+
+	// 非法的会话 ID 不会访问目录之外的文件
+	data, err := store.Load(nil, "../../etc/passwd")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+// TestSessionsCookieStore 测试基于签名 Cookie 的会话存储
+func TestSessionsCookieStore(t *testing.T) {
+	client := newSessionEngine(SessionConfig{Store: NewCookieSessionStore("")}, Config{SecretKeys: [][]byte{testKeyOld}})
+	runSessionFlow(t, client)
+}
+
+// TestSessionsExpiry 测试空闲超时与绝对超时
+func TestSessionsExpiry(t *testing.T) {
+	store := NewMemorySessionStore()
+	client := newSessionEngine(SessionConfig{Store: store, IdleTimeout: time.Hour, AbsoluteTimeout: 2 * time.Hour})
+	client.do("/login")
+	id := client.cookies[defaultSessionCookieName].Value
+
+	// 模拟超过空闲时间未访问
+	data, _ := store.Load(nil, id)
+	data.AccessedAt = time.Now().Add(-2 * time.Hour)
+	store.Save(nil, id, data, time.Hour)
+	assert.Empty(t, client.do("/me").Body.String())
+
+	// 持续访问也无法超过绝对超时
+	client.do("/login")
+	id = client.cookies[defaultSessionCookieName].Value
+	data, _ = store.Load(nil, id)
+	data.CreatedAt = time.Now().Add(-3 * time.Hour)
+	store.Save(nil, id, data, time.Hour)
+	assert.Empty(t, client.do("/me").Body.String())
+}
+
+// TestSessionWithoutMiddleware 测试未使用会话中间件
+func TestSessionWithoutMiddleware(t *testing.T) {
+	engine := NewEngine()
+	engine.GET("/", func(ctx *Context) error {
+		assert.Nil(t, ctx.Session())
+		return nil
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// TestSessionsTimeout 测试处理程序超时后访问会话不与响应提交竞争，也不会被保存
+func TestSessionsTimeout(t *testing.T) {
+	store := NewMemorySessionStore()
+	done := make(chan struct{})
+	engine := NewEngine()
+	engine.Use(Sessions(SessionConfig{Store: store}), Timeout(TimeoutConfig{Timeout: 10 * time.Millisecond}))
+	engine.GET("/slow", func(ctx *Context) error {
+		defer close(done)
+		time.Sleep(50 * time.Millisecond)
+		ctx.Session().Set("user", "kamalyes")
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	<-done
+	assert.Empty(t, recorder.Result().Cookies())
+	assert.Equal(t, 0, store.Len())
+}
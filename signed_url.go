@@ -0,0 +1,117 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 18:12:47
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:43:04
+ * @FilePath: \gosh\signed_url.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 签名链接的查询参数
+const (
+	SignedURLExpiresParam   = "expires"   // 过期时间（Unix 秒）
+	SignedURLSignatureParam = "signature" // 签名
+	SignedURLIPClaim        = "ip"        // 绑定的客户端 IP，只参与签名，不出现在链接中
+	urlSignPurpose          = "url-sign"  // 链接签名的密钥用途
+)
+
+// SignedURLConfig 签名链接校验配置
+type SignedURLConfig struct {
+	BindClientIP bool // 是否要求链接绑定客户端 IP，开启后签发时需要在 claims 中提供 SignedURLIPClaim
+}
+
+// SignURL 为路径签发带过期时间的链接，返回包含查询参数的相对地址
+// claims 会作为查询参数参与签名，篡改任意参数都会导致校验失败；
+// claims 中的 SignedURLIPClaim 只参与签名，校验时与请求的客户端 IP 比较
+func (engine *Engine) SignURL(path string, ttl time.Duration, claims url.Values) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	var clientIP string
+	for key, values := range claims {
+		if key == SignedURLIPClaim {
+			if len(values) > 0 {
+				clientIP = values[0]
+			}
+			continue
+		}
+		query[key] = append(query[key], values...)
+	}
+	query.Del(SignedURLSignatureParam)
+	query.Set(SignedURLExpiresParam, strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+
+	signature, err := engine.KeyRing().Sign(urlSignPurpose, canonicalSignedURL(u.Path, query, clientIP))
+	if err != nil {
+		return "", err
+	}
+	query.Set(SignedURLSignatureParam, base64.RawURLEncoding.EncodeToString(signature))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// VerifySignedURL 返回校验签名链接的中间件，签名无效或过期时返回 403 并中止请求
+// 通常挂载在 ServeFile、Static 等文件路由所在的路由组上
+func VerifySignedURL(config ...SignedURLConfig) HandlerFunc {
+	var cfg SignedURLConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return func(ctx *Context) error {
+		var clientIP string
+		if cfg.BindClientIP {
+			clientIP = ctx.ClientIP()
+		}
+		if err := ctx.verifySignedURL(clientIP); err != nil {
+			ctx.Abort()
+			ctx.Error = err
+			sceneCode := SceneCode(URLSignatureInvalid)
+			if errors.Is(err, errorsx.ErrSignedURLExpired) {
+				sceneCode = URLExpired
+			}
+			SendErrorResponse(ctx, &ResponseOption{SceneCode: sceneCode, HttpCode: StatusForbidden})
+		}
+		return nil
+	}
+}
+
+// verifySignedURL 校验当前请求的签名与过期时间
+func (ctx *Context) verifySignedURL(clientIP string) error {
+	query := ctx.Request.URL.Query()
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get(SignedURLSignatureParam))
+	if err != nil || len(signature) == 0 {
+		return errorsx.ErrSignedURLInvalid
+	}
+	query.Del(SignedURLSignatureParam)
+	if !ctx.Engine.KeyRing().Verify(urlSignPurpose, canonicalSignedURL(ctx.Request.URL.Path, query, clientIP), signature) {
+		return errorsx.ErrSignedURLInvalid
+	}
+	// 签名通过后过期时间可信
+	expires, err := strconv.ParseInt(query.Get(SignedURLExpiresParam), 10, 64)
+	if err != nil {
+		return errorsx.ErrSignedURLInvalid
+	}
+	if time.Now().Unix() > expires {
+		return errorsx.ErrSignedURLExpired
+	}
+	return nil
+}
+
+// canonicalSignedURL 生成参与签名的内容：路径、按键排序的查询参数与绑定的客户端 IP
+func canonicalSignedURL(path string, query url.Values, clientIP string) []byte {
+	return []byte(path + "\n" + query.Encode() + "\n" + clientIP)
+}
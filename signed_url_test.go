@@ -0,0 +1,102 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 18:24:52
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 18:24:52
+ * @FilePath: \gosh\signed_url_test.go
+ * @Description: 测试签名链接功能
+ */
+package gosh
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSignedURLEngine 创建需要签名才能下载文件的引擎
+func newSignedURLEngine(t *testing.T, config ...SignedURLConfig) *Engine {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "report.txt"), []byte("report"), 0o644))
+
+	engine := NewEngine(Config{SecretKeys: [][]byte{testKeyOld}})
+	files := engine.Group("/files", VerifySignedURL(config...))
+	files.StaticFile("/report.txt", filepath.Join(dir, "report.txt"))
+	files.StaticFile("/other.txt", filepath.Join(dir, "report.txt"))
+	return engine
+}
+
+// getSigned 使用指定的客户端地址请求链接
+func getSigned(engine *Engine, target, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// TestSignedURL 测试签发、校验、篡改与过期
+func TestSignedURL(t *testing.T) {
+	engine := newSignedURLEngine(t)
+
+	signed, err := engine.SignURL("/files/report.txt", time.Minute, url.Values{"uid": {"42"}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "/files/report.txt?"))
+	assert.Contains(t, signed, "uid=42")
+
+	recorder := getSigned(engine, signed, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "report", recorder.Body.String())
+
+	// 篡改参数或缺少签名
+	recorder = getSigned(engine, strings.Replace(signed, "uid=42", "uid=43", 1), "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "1014")
+	recorder = getSigned(engine, "/files/report.txt", "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// 签名不能用于其他路径
+	u, _ := url.Parse(signed)
+	recorder = getSigned(engine, "/files/other.txt?"+u.RawQuery, "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	expired, err := engine.SignURL("/files/report.txt", -time.Minute, nil)
+	assert.NoError(t, err)
+	recorder = getSigned(engine, expired, "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "1015")
+}
+
+// TestSignedURLKeyRotation 测试轮换密钥后旧链接仍然有效
+func TestSignedURLKeyRotation(t *testing.T) {
+	engine := newSignedURLEngine(t)
+	signed, err := engine.SignURL("/files/report.txt", time.Minute, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, engine.KeyRing().Rotate(testKeyNew))
+	assert.Equal(t, http.StatusOK, getSigned(engine, signed, "").Code)
+
+	// 旧密钥被移除后链接失效
+	assert.NoError(t, engine.KeyRing().SetKeys(testKeyNew))
+	assert.Equal(t, http.StatusForbidden, getSigned(engine, signed, "").Code)
+}
+
+// TestSignedURLBindClientIP 测试链接绑定客户端 IP
+func TestSignedURLBindClientIP(t *testing.T) {
+	engine := newSignedURLEngine(t, SignedURLConfig{BindClientIP: true})
+	signed, err := engine.SignURL("/files/report.txt", time.Minute, url.Values{SignedURLIPClaim: {"203.0.113.7"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, signed, "203.0.113.7")
+
+	assert.Equal(t, http.StatusOK, getSigned(engine, signed, "203.0.113.7:5000").Code)
+	assert.Equal(t, http.StatusForbidden, getSigned(engine, signed, "198.51.100.1:5000").Code)
+}
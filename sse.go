@@ -0,0 +1,110 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 10:05:48
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 10:05:48
+ * @FilePath: \gosh\sse.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// ServerSentEvent 表示一条 SSE 事件
+type ServerSentEvent struct {
+	ID    string // 事件 ID，客户端重连时会通过 Last-Event-ID 带回
+	Event string // 事件名称，为空时客户端按 message 处理
+	Data  []byte // 事件数据，多行数据会被拆分为多个 data 字段
+	Retry int    // 建议客户端的重连间隔（毫秒），为 0 时不发送
+}
+
+// encode 按 text/event-stream 格式编码事件
+func (e *ServerSentEvent) encode(buf *bytes.Buffer) {
+	if e.ID != "" {
+		buf.WriteString("id: " + strings.ReplaceAll(e.ID, "\n", "") + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + strings.ReplaceAll(e.Event, "\n", "") + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.Itoa(e.Retry) + "\n")
+	}
+	for _, line := range strings.Split(string(e.Data), "\n") {
+		buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buf.WriteString("\n")
+}
+
+// SetupSSE 设置 SSE 响应头并立即发送，之后即可持续写入事件
+func (ctx *Context) SetupSSE() {
+	header := ctx.ResponseWriter.Header()
+	header.Set(constants.HeaderContentTypeKey, constants.ContentTypeEventStream)
+	header.Set(constants.HeaderCacheControlKey, "no-cache")
+	header.Set(constants.HeaderConnectionKey, "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	ctx.Status = http.StatusOK
+	ctx.ResponseWriter.WriteHeader(http.StatusOK)
+	ctx.Flush()
+}
+
+// SSEvent 写入一条 SSE 事件并刷新，data 为 []byte 或 string 时原样发送，其它类型序列化为 JSON
+func (ctx *Context) SSEvent(event string, data any) error {
+	payload, err := encodeEventData(data)
+	if err != nil {
+		return err
+	}
+	return ctx.WriteServerSentEvent(&ServerSentEvent{Event: event, Data: payload})
+}
+
+// WriteServerSentEvent 写入一条完整的 SSE 事件并刷新
+func (ctx *Context) WriteServerSentEvent(event *ServerSentEvent) error {
+	var buf bytes.Buffer
+	event.encode(&buf)
+	if _, err := ctx.ResponseWriter.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	ctx.Flush()
+	return nil
+}
+
+// writeSSEComment 写入 SSE 注释行，常用于心跳保活
+func (ctx *Context) writeSSEComment(comment string) error {
+	if _, err := ctx.ResponseWriter.Write([]byte(": " + comment + "\n\n")); err != nil {
+		return err
+	}
+	ctx.Flush()
+	return nil
+}
+
+// Flush 将缓冲的响应数据立即发送给客户端
+func (ctx *Context) Flush() {
+	if flusher, ok := ctx.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// encodeEventData 将事件数据转换为字节切片
+func encodeEventData(data any) ([]byte, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case json.RawMessage:
+		return v, nil
+	default:
+		return json.Marshal(v)
+	}
+}
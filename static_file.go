@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-11 08:59:07
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-15 21:51:51
+ * @LastEditTime: 2026-10-19 06:41:44
  * @FilePath: \gosh\static_file.go
  * @Description:
  *
@@ -20,16 +20,14 @@ import (
 // StaticFile 注册一个指向服务端本地文件的静态路由
 func (group *RouterGroup) StaticFile(relativePath, filePath string) {
 	group.staticFileHandler(relativePath, func(ctx *Context) error {
-		ctx.ServeFile(filePath)
-		return nil
+		return ctx.ServeFile(filePath)
 	})
 }
 
 // StaticFileFS 与StaticFile函数类型，但可以自定义文件系统
 func (group *RouterGroup) StaticFileFS(relativePath, filePath string, fs http.FileSystem) {
 	group.staticFileHandler(relativePath, func(ctx *Context) error {
-		ctx.FileFromFS(filePath, fs)
-		return nil
+		return ctx.FileFromFS(filePath, fs)
 	})
 }
 
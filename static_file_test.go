@@ -0,0 +1,164 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 17:31:06
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:41:44
+ * @FilePath: \gosh\static_file_test.go
+ * @Description: 测试文件服务的条件请求与范围请求功能
+ */
+package gosh
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newStaticFileEngine 创建提供同一文件的本地文件与文件系统两个路由
+func newStaticFileEngine(t *testing.T, name string, content []byte) (*Engine, os.FileInfo) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, content, 0o644))
+	modTime := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	engine := NewEngine()
+	engine.StaticFile("/file", path)
+	engine.StaticFileFS("/fs", name, http.Dir(dir))
+	return engine, info
+}
+
+// serveStatic 发送请求并返回响应
+func serveStatic(engine *Engine, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// TestServeFileConditional 测试 ETag、Last-Modified 与 304 响应
+func TestServeFileConditional(t *testing.T) {
+	engine, info := newStaticFileEngine(t, "index.html", []byte("<html>hello</html>"))
+
+	for _, target := range []string{"/file", "/fs"} {
+		recorder := serveStatic(engine, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "<html>hello</html>", recorder.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+		assert.Equal(t, info.ModTime().UTC().Format(http.TimeFormat), recorder.Header().Get("Last-Modified"))
+		etag := recorder.Header().Get("ETag")
+		assert.Equal(t, fileETag(info), etag)
+
+		recorder = serveStatic(engine, http.MethodGet, target, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+		assert.Empty(t, recorder.Body.String())
+
+		recorder = serveStatic(engine, http.MethodGet, target, map[string]string{"If-Modified-Since": info.ModTime().UTC().Format(http.TimeFormat)})
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+		recorder = serveStatic(engine, http.MethodGet, target, map[string]string{"If-None-Match": `"other"`})
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		recorder = serveStatic(engine, http.MethodHead, target, nil)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Body.String())
+	}
+}
+
+// TestServeFileRange 测试单范围、多范围与 If-Range
+func TestServeFileRange(t *testing.T) {
+	engine, info := newStaticFileEngine(t, "video.mp4", []byte("0123456789abcdef"))
+
+	recorder := serveStatic(engine, http.MethodGet, "/file", map[string]string{"Range": "bytes=2-5"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "2345", recorder.Body.String())
+	assert.Equal(t, "bytes 2-5/16", recorder.Header().Get("Content-Range"))
+
+	recorder = serveStatic(engine, http.MethodGet, "/fs", map[string]string{"Range": "bytes=-3"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "def", recorder.Body.String())
+
+	recorder = serveStatic(engine, http.MethodGet, "/file", map[string]string{"Range": "bytes=100-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, recorder.Code)
+
+	// 多范围返回 multipart/byteranges
+	recorder = serveStatic(engine, http.MethodGet, "/file", map[string]string{"Range": "bytes=0-1,10-11"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+	reader := multipart.NewReader(recorder.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(part)
+		parts = append(parts, part.Header.Get("Content-Range")+"="+string(body))
+	}
+	assert.Equal(t, "bytes 0-1/16=01,bytes 10-11/16=ab", strings.Join(parts, ","))
+
+	// If-Range 与 ETag 一致时返回范围内容，不一致时返回完整文件
+	recorder = serveStatic(engine, http.MethodGet, "/file", map[string]string{"Range": "bytes=0-3", "If-Range": fileETag(info)})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	recorder = serveStatic(engine, http.MethodGet, "/file", map[string]string{"Range": "bytes=0-3", "If-Range": `"stale"`})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "0123456789abcdef", recorder.Body.String())
+}
+
+// TestServeFileSniff 测试没有扩展名时嗅探内容类型
+func TestServeFileSniff(t *testing.T) {
+	engine, _ := newStaticFileEngine(t, "README", []byte("%PDF-1.4 fake pdf content"))
+	recorder := serveStatic(engine, http.MethodGet, "/file", nil)
+	assert.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+}
+
+// TestServeFileDirectory 测试目录按 404 错误处理
+func TestServeFileDirectory(t *testing.T) {
+	dir := t.TempDir()
+	engine := NewEngine()
+	engine.GET("/dir", func(ctx *Context) error {
+		return ctx.ServeFile(dir)
+	})
+	engine.GET("/fs", func(ctx *Context) error {
+		return ctx.FileFromFS("/", http.Dir(dir))
+	})
+
+	for _, target := range []string{"/dir", "/fs"} {
+		recorder := serveStatic(engine, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusNotFound, recorder.Code, target)
+		assert.Contains(t, recorder.Body.String(), "文件未找到", target)
+	}
+}
+
+// TestServeFileMissing 测试文件不存在时按 Accept 协商 404 错误页面
+func TestServeFileMissing(t *testing.T) {
+	dir := t.TempDir()
+	engine := NewEngine()
+	engine.StaticFile("/file", filepath.Join(dir, "missing.html"))
+	engine.StaticFileFS("/fs", "missing.html", http.Dir(dir))
+
+	for _, target := range []string{"/file", "/fs"} {
+		recorder := serveStatic(engine, http.MethodGet, target, map[string]string{"Accept": browserAccept})
+		assert.Equal(t, http.StatusNotFound, recorder.Code, target)
+		assert.Contains(t, recorder.Header().Get("Content-Type"), "text/html", target)
+		assert.Contains(t, recorder.Body.String(), "文件未找到", target)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 18:41:26
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:07:39
+ * @FilePath: \gosh\timeout.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// TimeoutMetaKey 路由元数据中的超时时间，值为 time.Duration，优先于 TimeoutConfig.Timeout
+const TimeoutMetaKey = "timeout"
+
+// TimeoutConfig 超时中间件配置
+type TimeoutConfig struct {
+	Timeout time.Duration // 默认超时时间，为 0 且路由没有设置超时时间时不限制
+	// OnFinish 在处理程序真正返回时调用，timedOut 表示此前是否已经超时
+	// 超时后处理程序仍在运行时，该回调在处理程序所在的 goroutine 中执行，只能使用传入的 ctx
+	OnFinish func(ctx *Context, timedOut bool)
+}
+
+// Timeout 返回超时中间件，可以通过 Engine.Use 全局使用、挂载到路由组，
+// 或者通过 RouterGroup.WithMeta(TimeoutMetaKey, d) 为单个路由设置超时时间
+//
+// 后续处理程序在独立的 goroutine 中运行，Context.Deadline/Done 返回派生的截止时间；
+// 响应先写入缓冲区，按时完成时再一次性写出；超时后返回 503 与 Deadline 业务码，
+// 处理程序之后的写入会被丢弃。因此超时路由不支持流式响应与连接劫持
+func Timeout(config TimeoutConfig) HandlerFunc {
+	return func(ctx *Context) error {
+		timeout := config.Timeout
+		if value, exists := ctx.RouteMeta(TimeoutMetaKey); exists {
+			if d, ok := value.(time.Duration); ok {
+				timeout = d
+			}
+		}
+		if timeout <= 0 {
+			return nil
+		}
+
+		deadlineCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		buffer := newTimeoutWriter(deadlineCtx)
+		inner := ctx.forkForTimeout(ctx.Request.WithContext(deadlineCtx), buffer)
+		ctx.index = int8(len(ctx.handlers)) // 后续处理程序交给 inner 执行
+
+		done := make(chan struct{})
+		var panicValue *stackPanic
+		go func() {
+			defer close(done)
+			defer func() {
+				if p := recover(); p != nil {
+					panicValue = &stackPanic{value: p, stack: debug.Stack()} // 保留处理程序所在 goroutine 的堆栈
+				}
+			}()
+			inner.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-deadlineCtx.Done():
+		}
+		// 截止时间之后的写入已被缓冲区拒绝，只有在截止时间之前完成才算按时完成
+		if deadlineCtx.Err() == nil {
+			cancel()
+			if panicValue != nil {
+				panic(panicValue) // 交给 Recovery 处理
+			}
+			ctx.joinTimeout(inner, buffer)
+			if config.OnFinish != nil {
+				config.OnFinish(ctx, false)
+			}
+			return nil
+		}
+
+		// 已超时，由处理程序所在的 goroutine 负责收尾
+		go func() {
+			<-done
+			cancel()
+			if panicValue != nil {
+				inner.logPrintln("超时后处理程序发生 panic:", panicValue.value)
+			}
+			inner.releaseBody()
+			if config.OnFinish != nil {
+				config.OnFinish(inner, true)
+			}
+		}()
+
+		ctx.Abort()
+		ctx.timedOut = true
+		ctx.Error = errorsx.ErrHandlerTimeout
+		if errors.Is(deadlineCtx.Err(), context.Canceled) || ctx.Writer().Written() {
+			return nil // 客户端已断开连接
+		}
+		return SendErrorResponse(ctx, &ResponseOption{SceneCode: Deadline, HttpCode: StatusServiceUnavailable})
+	}
+}
+
+// TimedOut 返回请求是否因超时中间件而返回 503，处理程序没有在截止时间前完成
+func (ctx *Context) TimedOut() bool {
+	return ctx.timedOut
+}
+
+// forkForTimeout 复制出在独立 goroutine 中执行后续处理程序的上下文
+// 路径参数被复制，请求体的所有权转移给副本，这样超时后原上下文可以安全地放回池中
+func (ctx *Context) forkForTimeout(req *http.Request, w http.ResponseWriter) *Context {
+	inner := ctx.Copy()
+	inner.Request = req
+	inner.ResponseWriter = w
+	inner.sessionState = ctx.sessionState
+	if ctx.params != nil {
+		params := append(make(Params, 0, len(*ctx.params)), *ctx.params...)
+		inner.params = &params
+	}
+
+	inner.rawBody, ctx.rawBody = ctx.rawBody, nil
+	inner.bodyCache, ctx.bodyCache = ctx.bodyCache, nil
+	inner.bodyLimit = ctx.bodyLimit
+	inner.cacheBody = ctx.cacheBody
+	inner.bodySpillThreshold = ctx.bodySpillThreshold
+	return inner
+}
+
+// joinTimeout 处理程序按时完成后，把副本的状态与缓冲的响应合并回原上下文
+func (ctx *Context) joinTimeout(inner *Context, buffer *timeoutWriter) {
+	ctx.Status = inner.Status
+	ctx.Error = inner.Error
+	ctx.errs = inner.Errors() // 副本复制时已包含原上下文的错误
+	ctx.broke = inner.broke
+	ctx.language = inner.language
+	for key, value := range inner.Keys {
+		ctx.Set(key, value)
+	}
+	ctx.rawBody = inner.rawBody
+	ctx.bodyCache = inner.bodyCache
+	ctx.bodyLimit = inner.bodyLimit
+	ctx.cacheBody = inner.cacheBody
+	ctx.bodySpillThreshold = inner.bodySpillThreshold
+
+	header := ctx.ResponseWriter.Header()
+	for key, values := range buffer.header {
+		header[key] = values
+	}
+	if buffer.wroteHeader {
+		ctx.ResponseWriter.WriteHeader(buffer.status)
+		if buffer.body.Len() > 0 {
+			ctx.ResponseWriter.Write(buffer.body.Bytes())
+		}
+	}
+}
+
+// timeoutWriter 缓冲处理程序的响应，超过截止时间后拒绝写入
+type timeoutWriter struct {
+	mu          sync.Mutex
+	deadline    context.Context
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+// newTimeoutWriter 创建缓冲写入器
+func newTimeoutWriter(deadline context.Context) *timeoutWriter {
+	return &timeoutWriter{deadline: deadline, header: make(http.Header), status: http.StatusOK}
+}
+
+// Header 返回缓冲的响应头
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 记录状态码
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deadline.Err() != nil || w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+}
+
+// Write 写入缓冲区，超过截止时间后返回 errorsx.ErrHandlerTimeout
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deadline.Err() != nil {
+		return 0, errorsx.ErrHandlerTimeout
+	}
+	w.wroteHeader = true
+	return w.body.Write(data)
+}
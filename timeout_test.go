@@ -0,0 +1,134 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 19:02:35
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 19:02:35
+ * @FilePath: \gosh\timeout_test.go
+ * @Description: 测试超时中间件功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimeoutFinished 测试按时完成时输出缓冲的响应并合并状态
+func TestTimeoutFinished(t *testing.T) {
+	var timedOut, finished bool
+	engine := NewEngine()
+	engine.Use(func(ctx *Context) error {
+		ctx.Next()
+		timedOut = ctx.TimedOut()
+		assert.Equal(t, "kamalyes", ctx.MustGet("user")) // 处理程序设置的键值被合并回来
+		return nil
+	})
+	engine.Use(Timeout(TimeoutConfig{Timeout: time.Second, OnFinish: func(ctx *Context, late bool) { finished = !late }}))
+	engine.GET("/users/:id", func(ctx *Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		ctx.Set("user", "kamalyes")
+		ctx.SetHeader("X-User", ctx.PathValue("id"))
+		return ctx.WriteString(http.StatusCreated, "created")
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "created", recorder.Body.String())
+	assert.Equal(t, "42", recorder.Header().Get("X-User"))
+	assert.False(t, timedOut)
+	assert.True(t, finished)
+}
+
+// TestTimeoutExpired 测试超时返回 503，超时后的写入被丢弃
+func TestTimeoutExpired(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	finished := make(chan bool, 1)
+	var timedOut bool
+	engine := NewEngine()
+	engine.Use(func(ctx *Context) error {
+		ctx.Next()
+		timedOut = ctx.TimedOut()
+		return nil
+	})
+	engine.Use(Timeout(TimeoutConfig{
+		Timeout:  20 * time.Millisecond,
+		OnFinish: func(ctx *Context, late bool) { finished <- late },
+	}))
+	engine.GET("/slow", func(ctx *Context) error {
+		<-ctx.Done()
+		lateWrite <- ctx.WriteString(http.StatusOK, "too late")
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.True(t, timedOut)
+
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, float64(Deadline), resp["code"])
+
+	assert.Equal(t, errorsx.ErrHandlerTimeout, <-lateWrite)
+	assert.True(t, <-finished)
+	assert.NotContains(t, recorder.Body.String(), "too late")
+}
+
+// TestTimeoutRouteMeta 测试通过路由元数据覆盖超时时间
+func TestTimeoutRouteMeta(t *testing.T) {
+	engine := NewEngine()
+	api := engine.Group("/api", Timeout(TimeoutConfig{Timeout: 20 * time.Millisecond}))
+	handler := func(ctx *Context) error {
+		select {
+		case <-time.After(60 * time.Millisecond):
+			return ctx.WriteString(http.StatusOK, "done")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	api.GET("/fast", handler)
+	api.WithMeta(TimeoutMetaKey, time.Second).GET("/report", handler)
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/fast", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/report", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "done", recorder.Body.String())
+
+	// 元数据出现在路由信息中，且不影响原路由组
+	for _, route := range engine.GetAllRoutes() {
+		switch route.Path {
+		case "/api/report":
+			assert.Equal(t, time.Second, route.Meta[TimeoutMetaKey])
+		case "/api/fast":
+			assert.Nil(t, route.Meta)
+		}
+	}
+}
+
+// TestTimeoutPanic 测试按时发生的 panic 交给 Recovery 处理
+func TestTimeoutPanic(t *testing.T) {
+	engine := NewEngine(Config{Recovery: true})
+	engine.Use(Timeout(TimeoutConfig{Timeout: time.Second}))
+	engine.GET("/panic", func(ctx *Context) error {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
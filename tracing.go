@@ -0,0 +1,491 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 19:58:14
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 19:58:14
+ * @FilePath: \gosh\tracing.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+)
+
+// 常量定义
+const (
+	traceParentLength    = 55                         // 版本 00 的 traceparent 长度
+	maxTraceStateMembers = 32                         // tracestate 最多包含的成员数
+	traceFlagSampled     = 0x01                       // traceparent 中的采样标志位
+	tracingScopeName     = "github.com/kamalyes/gosh" // 导出时使用的 instrumentation scope 名称
+	defaultServiceName   = "gosh"                     // 没有配置服务名称时使用的默认值
+)
+
+// TraceID 16 字节的链路 ID
+type TraceID [16]byte
+
+// String 返回小写十六进制表示
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid 全为 0 的链路 ID 无效
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID 8 字节的 Span ID
+type SpanID [8]byte
+
+// String 返回小写十六进制表示
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid 全为 0 的 Span ID 无效
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext 在服务之间传递的链路上下文，对应 traceparent 与 tracestate 请求头
+type SpanContext struct {
+	TraceID    TraceID // 链路 ID
+	SpanID     SpanID  // 当前 Span ID
+	Flags      byte    // 链路标志，最低位表示是否采样
+	TraceState string  // 厂商自定义的链路状态，原样传递
+	Remote     bool    // 是否从上游请求头中解析得到
+}
+
+// IsValid 链路 ID 与 Span ID 都有效时返回 true
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled 返回是否采样
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&traceFlagSampled != 0
+}
+
+// TraceParent 返回版本 00 的 traceparent 请求头值
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// Inject 把链路上下文写入请求头，用于向下游服务发起请求时传递链路
+func (sc SpanContext) Inject(header http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(constants.HeaderTraceParentKey, sc.TraceParent())
+	if sc.TraceState != "" {
+		header.Set(constants.HeaderTraceStateKey, sc.TraceState)
+	}
+}
+
+// ParseTraceParent 按 W3C Trace Context 规范解析 traceparent 请求头
+func ParseTraceParent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	if len(value) < traceParentLength || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, false
+	}
+	var version [1]byte
+	if !decodeLowerHex(version[:], value[0:2]) || version[0] == 0xff {
+		return sc, false
+	}
+	// 版本 00 长度固定，更高版本允许在末尾以 '-' 追加字段
+	if len(value) > traceParentLength && (version[0] == 0 || value[traceParentLength] != '-') {
+		return sc, false
+	}
+	var flags [1]byte
+	if !decodeLowerHex(sc.TraceID[:], value[3:35]) ||
+		!decodeLowerHex(sc.SpanID[:], value[36:52]) ||
+		!decodeLowerHex(flags[:], value[53:55]) {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Flags = flags[0]
+	sc.Remote = true
+	return sc, true
+}
+
+// ParseTraceState 解析 tracestate 请求头，去掉空成员；格式不合法或成员过多时整体丢弃
+func ParseTraceState(value string) string {
+	members := make([]string, 0, 4)
+	for _, member := range strings.Split(value, ",") {
+		member = strings.Trim(member, " \t")
+		if member == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(member, "=")
+		if !ok || !validTraceStateKey(key) || !validTraceStateValue(val) {
+			return ""
+		}
+		members = append(members, member)
+	}
+	if len(members) > maxTraceStateMembers {
+		return ""
+	}
+	return strings.Join(members, ",")
+}
+
+// validTraceStateKey 校验 tracestate 的键：小写字母开头，可以包含 "租户@厂商" 形式
+func validTraceStateKey(key string) bool {
+	if key == "" || len(key) > 256 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '_' || c == '-' || c == '*' || c == '/' || c == '@':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validTraceStateValue 校验 tracestate 的值：可见 ASCII 字符，不包含 ',' 与 '='
+func validTraceStateValue(value string) bool {
+	if value == "" || len(value) > 256 || value[len(value)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeLowerHex 解码小写十六进制字符串，规范不允许大写
+func decodeLowerHex(dst []byte, src string) bool {
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+// spanContextKey 链路上下文在 context.Context 中的键
+type spanContextKey struct{}
+
+// SpanContextFromContext 从 context.Context 中读取服务端 Span 的链路上下文，
+// 可用于只拿到 Request.Context() 的下游代码向其他服务传递链路
+func SpanContextFromContext(c context.Context) SpanContext {
+	sc, _ := c.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// SpanKind Span 类型，取值与 OTLP 一致
+type SpanKind int
+
+// Span 类型常量
+const (
+	SpanKindInternal SpanKind = 1 // 进程内部的操作，例如中间件与处理程序
+	SpanKindServer   SpanKind = 2 // 服务端处理的请求
+	SpanKindClient   SpanKind = 3 // 客户端发起的请求
+)
+
+// SpanStatusCode Span 状态码，取值与 OTLP 一致
+type SpanStatusCode int
+
+// Span 状态码常量
+const (
+	SpanStatusUnset SpanStatusCode = 0 // 未设置
+	SpanStatusOK    SpanStatusCode = 1 // 成功
+	SpanStatusError SpanStatusCode = 2 // 失败
+)
+
+// SpanData 结束后的 Span 数据，交给导出器使用
+type SpanData struct {
+	Name          string         // Span 名称
+	Kind          SpanKind       // Span 类型
+	SpanContext   SpanContext    // 链路上下文
+	ParentSpanID  SpanID         // 父 Span ID，根 Span 为空
+	StartTime     time.Time      // 开始时间
+	EndTime       time.Time      // 结束时间
+	Attributes    map[string]any // 属性
+	StatusCode    SpanStatusCode // 状态码
+	StatusMessage string         // 状态说明
+	ServiceName   string         // 所属服务名称
+}
+
+// Span 一次操作的耗时与属性记录，方法可以在 nil 上调用
+type Span struct {
+	mu    sync.Mutex
+	batch *spanBatch
+	data  SpanData
+	ended bool
+}
+
+// SpanContext 返回 Span 的链路上下文
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// IsRecording 返回 Span 是否被采样记录
+func (s *Span) IsRecording() bool {
+	return s != nil && s.data.SpanContext.IsSampled()
+}
+
+// SetAttribute 设置属性，值建议使用字符串、布尔与数值类型
+func (s *Span) SetAttribute(key string, value any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+}
+
+// SetStatus 设置状态码与说明
+func (s *Span) SetStatus(code SpanStatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.data.StatusCode = code
+	s.data.StatusMessage = message
+}
+
+// RecordError 记录错误并把状态设置为失败，err 为 nil 时不做任何处理
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetAttribute("exception.message", err.Error())
+	s.SetStatus(SpanStatusError, err.Error())
+}
+
+// End 结束 Span，重复调用无效
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.batch.add(data)
+}
+
+// startChild 创建子 Span，继承链路 ID 与采样标志
+func (s *Span) startChild(name string, kind SpanKind) *Span {
+	sc := s.data.SpanContext
+	sc.SpanID = newSpanID()
+	sc.Remote = false
+	return &Span{
+		batch: s.batch,
+		data: SpanData{
+			Name:         name,
+			Kind:         kind,
+			SpanContext:  sc,
+			ParentSpanID: s.data.SpanContext.SpanID,
+			StartTime:    time.Now(),
+			ServiceName:  s.data.ServiceName,
+		},
+	}
+}
+
+// spanBatch 同一个请求内的 Span，服务端 Span 结束时一次性导出
+// 服务端 Span 结束之后才结束的 Span（例如超时后仍在运行的处理程序）单独导出
+type spanBatch struct {
+	mu       sync.Mutex
+	exporter SpanExporter
+	root     SpanID
+	spans    []SpanData
+	flushed  bool
+}
+
+// add 加入结束的 Span
+func (b *spanBatch) add(data SpanData) {
+	if b.exporter == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.flushed {
+		b.mu.Unlock()
+		b.export([]SpanData{data})
+		return
+	}
+	b.spans = append(b.spans, data)
+	if data.SpanContext.SpanID != b.root {
+		b.mu.Unlock()
+		return
+	}
+	spans := b.spans
+	b.spans = nil
+	b.flushed = true
+	b.mu.Unlock()
+	b.export(spans)
+}
+
+// export 调用导出器，失败时输出日志
+func (b *spanBatch) export(spans []SpanData) {
+	if err := b.exporter.ExportSpans(context.Background(), spans); err != nil {
+		log.Println("导出链路数据失败:", err)
+	}
+}
+
+// TracingConfig 链路追踪中间件配置
+type TracingConfig struct {
+	Exporter    SpanExporter            // 链路数据导出器，为空时只传递链路上下文不导出
+	ServiceName string                  // 服务名称(默认Config.AppName)
+	Sampler     func(ctx *Context) bool // 没有上游链路时是否采样(默认全部采样)，有上游链路时沿用上游的采样标志
+}
+
+// Tracing 返回链路追踪中间件
+// 解析请求头中的 traceparent/tracestate，为请求创建以 FullPath 命名的服务端 Span，
+// 之后的每个中间件与处理程序都会记录一个以 getHandlerName 命名的子 Span；
+// 响应头会带上本服务的 traceparent，处理程序可以通过 Context.Span 添加属性或向下游传递链路
+func Tracing(config TracingConfig) HandlerFunc {
+	return func(ctx *Context) error {
+		parent, ok := ParseTraceParent(ctx.Header(constants.HeaderTraceParentKey))
+		sc := SpanContext{SpanID: newSpanID()}
+		if ok {
+			sc.TraceID = parent.TraceID
+			sc.Flags = parent.Flags
+			sc.TraceState = ParseTraceState(ctx.Header(constants.HeaderTraceStateKey))
+		} else {
+			sc.TraceID = newTraceID()
+			if config.Sampler == nil || config.Sampler(ctx) {
+				sc.Flags = traceFlagSampled
+			}
+		}
+
+		name := ctx.FullPath()
+		if name == "" {
+			name = ctx.Method()
+		}
+		span := &Span{
+			batch: &spanBatch{exporter: config.Exporter, root: sc.SpanID},
+			data: SpanData{
+				Name:         name,
+				Kind:         SpanKindServer,
+				SpanContext:  sc,
+				ParentSpanID: parent.SpanID,
+				StartTime:    time.Now(),
+				ServiceName:  tracingServiceName(ctx, config),
+			},
+		}
+		span.SetAttribute("http.request.method", ctx.Method())
+		span.SetAttribute("url.path", ctx.Path())
+		span.SetAttribute("http.route", ctx.FullPath())
+		span.SetAttribute("client.address", ctx.ClientIP())
+		if ua := ctx.UserAgent(); ua != "" {
+			span.SetAttribute("user_agent.original", ua)
+		}
+
+		ctx.span = span
+		ctx.SetContextValue(spanContextKey{}, sc)
+		ctx.SetHeader(constants.HeaderTraceParentKey, sc.TraceParent())
+
+		completed := false
+		defer func() {
+			ctx.span = nil
+			if !completed {
+				span.SetStatus(SpanStatusError, "panic") // 交给 Recovery 处理，这里只记录
+			}
+			span.End()
+		}()
+
+		ctx.Next()
+		status := ctx.Writer().Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(SpanStatusError, http.StatusText(status))
+		}
+		completed = true
+		return nil
+	}
+}
+
+// tracingServiceName 返回服务名称
+func tracingServiceName(ctx *Context, config TracingConfig) string {
+	if config.ServiceName != "" {
+		return config.ServiceName
+	}
+	if ctx.Engine != nil && ctx.Engine.Config.AppName != "" {
+		return ctx.Engine.Config.AppName
+	}
+	return defaultServiceName
+}
+
+// Span 返回当前正在执行的处理程序对应的 Span，没有使用 Tracing 中间件时返回 nil
+// 返回值可以直接调用方法，例如 ctx.Span().SpanContext().Inject(req.Header) 向下游传递链路
+func (ctx *Context) Span() *Span {
+	return ctx.span
+}
+
+// runHandler 执行处理程序，开启链路追踪且被采样时为其记录子 Span
+func (ctx *Context) runHandler(handler HandlerFunc) error {
+	parent := ctx.span
+	if !parent.IsRecording() {
+		return handler(ctx)
+	}
+	span := parent.startChild(getHandlerName(handler), SpanKindInternal)
+	ctx.span = span
+	defer func() {
+		ctx.span = parent
+		span.End()
+	}()
+	err := handler(ctx)
+	span.RecordError(err)
+	return err
+}
+
+// newTraceID 生成随机的链路 ID
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(err)
+		}
+	}
+	return id
+}
+
+// newSpanID 生成随机的 Span ID
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(err)
+		}
+	}
+	return id
+}
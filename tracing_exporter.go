@@ -0,0 +1,234 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 20:12:37
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 20:12:37
+ * @FilePath: \gosh\tracing_exporter.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// SpanExporter 链路数据导出器，实现需要并发安全
+type SpanExporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error // 导出一批结束的 Span
+	Shutdown(ctx context.Context) error                      // 关闭导出器，释放资源
+}
+
+// InMemoryExporter 把 Span 保存在内存中，用于测试
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter 创建内存导出器
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpans 保存 Span
+func (e *InMemoryExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Shutdown 内存导出器无需释放资源
+func (e *InMemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Spans 返回已导出 Span 的副本，按导出顺序排列
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Reset 清空已导出的 Span
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// OTLPFileExporter 以 OTLP/JSON 格式把 Span 追加写入文件，每次导出占一行，
+// 与 OpenTelemetry Collector 的 file exporter 格式一致，可以通过 otlpjsonfile receiver 读取
+type OTLPFileExporter struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewOTLPFileExporter 创建 OTLP/JSON 文件导出器，文件不存在时自动创建
+func NewOTLPFileExporter(path string) (*OTLPFileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &OTLPFileExporter{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// ExportSpans 写入一行 ExportTraceServiceRequest
+func (e *OTLPFileExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	request := newOTLPTraceRequest(spans)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return errorsx.ErrSpanExporterClosed
+	}
+	return e.encoder.Encode(request)
+}
+
+// Shutdown 关闭文件，之后的导出返回 errorsx.ErrSpanExporterClosed
+func (e *OTLPFileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
+
+// OTLP/JSON 编码使用的结构，字段名与取值遵循 OTLP 规范：
+// ID 使用十六进制字符串，64 位整数使用十进制字符串，枚举使用整数
+type (
+	otlpTraceRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		TraceState        string         `json:"traceState,omitempty"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Flags             uint32         `json:"flags,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    SpanStatusCode `json:"code,omitempty"`
+		Message string         `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// newOTLPTraceRequest 按服务名称分组转换 Span
+func newOTLPTraceRequest(spans []SpanData) otlpTraceRequest {
+	var request otlpTraceRequest
+	index := make(map[string]int)
+	for _, span := range spans {
+		i, ok := index[span.ServiceName]
+		if !ok {
+			i = len(request.ResourceSpans)
+			index[span.ServiceName] = i
+			request.ResourceSpans = append(request.ResourceSpans, otlpResourceSpans{
+				Resource:   otlpResource{Attributes: []otlpKeyValue{otlpAttribute("service.name", span.ServiceName)}},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: tracingScopeName}}},
+			})
+		}
+		scope := &request.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, newOTLPSpan(span))
+	}
+	return request
+}
+
+// newOTLPSpan 转换单个 Span
+func newOTLPSpan(span SpanData) otlpSpan {
+	out := otlpSpan{
+		TraceID:           span.SpanContext.TraceID.String(),
+		SpanID:            span.SpanContext.SpanID.String(),
+		TraceState:        span.SpanContext.TraceState,
+		Flags:             uint32(span.SpanContext.Flags),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+		Status:            otlpStatus{Code: span.StatusCode, Message: span.StatusMessage},
+	}
+	if span.ParentSpanID.IsValid() {
+		out.ParentSpanID = span.ParentSpanID.String()
+	}
+
+	keys := make([]string, 0, len(span.Attributes))
+	for key := range span.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out.Attributes = append(out.Attributes, otlpAttribute(key, span.Attributes[key]))
+	}
+	return out
+}
+
+// otlpAttribute 按值的类型转换属性
+func otlpAttribute(key string, value any) otlpKeyValue {
+	var v otlpAnyValue
+	switch val := value.(type) {
+	case string:
+		v.StringValue = &val
+	case bool:
+		v.BoolValue = &val
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(val)
+		v.IntValue = &s
+	case float32:
+		f := float64(val)
+		v.DoubleValue = &f
+	case float64:
+		v.DoubleValue = &val
+	default:
+		s := fmt.Sprint(val)
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
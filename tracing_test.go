@@ -0,0 +1,221 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 20:26:03
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 20:26:03
+ * @FilePath: \gosh\tracing_test.go
+ * @Description: 测试链路追踪功能
+ */
+package gosh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// TestParseTraceParent 测试 traceparent 解析
+func TestParseTraceParent(t *testing.T) {
+	sc, ok := ParseTraceParent(testTraceParent)
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.IsSampled())
+	assert.True(t, sc.Remote)
+	assert.Equal(t, testTraceParent, sc.TraceParent())
+
+	// 更高版本允许追加字段
+	_, ok = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+
+	for _, value := range []string{
+		"",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", // 大写
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // 全 0 链路 ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", // 全 0 Span ID
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", // 非法版本
+		testTraceParent + "-extra",                                // 版本 00 不允许追加字段
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+	} {
+		_, ok := ParseTraceParent(value)
+		assert.False(t, ok, value)
+	}
+}
+
+// TestParseTraceState 测试 tracestate 解析
+func TestParseTraceState(t *testing.T) {
+	assert.Equal(t, "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE", ParseTraceState(" rojo=00f067aa0ba902b7 ,, congo=t61rcWkgMzE"))
+	assert.Equal(t, "tenant@vendor=1", ParseTraceState("tenant@vendor=1"))
+	assert.Equal(t, "", ParseTraceState("Rojo=1"))
+	assert.Equal(t, "", ParseTraceState("rojo"))
+	assert.Equal(t, "", ParseTraceState("rojo=a,b"))
+
+	members := make([]string, 33)
+	for i := range members {
+		members[i] = "k" + string(rune('a'+i%26)) + string(rune('a'+i/26)) + "=v"
+	}
+	assert.Equal(t, "", ParseTraceState(strings.Join(members, ",")))
+}
+
+// newTracingEngine 创建开启链路追踪的引擎
+func newTracingEngine(exporter SpanExporter) *Engine {
+	engine := NewEngine(Config{AppName: "orders"})
+	engine.Use(Tracing(TracingConfig{Exporter: exporter}))
+	return engine
+}
+
+// TestTracingSpans 测试服务端 Span 与处理程序子 Span 的层级
+func TestTracingSpans(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	engine := newTracingEngine(exporter)
+	auth := func(ctx *Context) error {
+		ctx.Next()
+		return nil
+	}
+	var downstream http.Header
+	engine.GET("/users/:id", auth, func(ctx *Context) error {
+		ctx.Span().SetAttribute("user.id", ctx.PathValue("id"))
+		downstream = make(http.Header)
+		ctx.Span().SpanContext().Inject(downstream)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", SpanContextFromContext(ctx.Request.Context()).TraceID.String())
+		return ctx.WriteString(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(constants.HeaderTraceParentKey, testTraceParent)
+	req.Header.Set(constants.HeaderTraceStateKey, "rojo=00f067aa0ba902b7")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	spans := exporter.Spans()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	// 按结束顺序导出：处理程序、中间件、服务端
+	handler, middleware, server := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "/users/:id", server.Name)
+	assert.Equal(t, SpanKindServer, server.Kind)
+	assert.Equal(t, "orders", server.ServiceName)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID.String())
+	assert.Equal(t, "rojo=00f067aa0ba902b7", server.SpanContext.TraceState)
+	assert.Equal(t, http.StatusOK, server.Attributes["http.response.status_code"])
+	assert.Equal(t, "/users/:id", server.Attributes["http.route"])
+	assert.Equal(t, server.SpanContext.TraceParent(), recorder.Header().Get(constants.HeaderTraceParentKey))
+
+	assert.Equal(t, SpanKindInternal, middleware.Kind)
+	assert.Equal(t, server.SpanContext.SpanID, middleware.ParentSpanID)
+	assert.Equal(t, middleware.SpanContext.SpanID, handler.ParentSpanID)
+	assert.Equal(t, "42", handler.Attributes["user.id"])
+	for _, span := range spans {
+		assert.Equal(t, server.SpanContext.TraceID, span.SpanContext.TraceID)
+		assert.False(t, span.EndTime.Before(span.StartTime))
+	}
+
+	// 向下游传递的是处理程序自己的 Span
+	assert.Equal(t, handler.SpanContext.TraceParent(), downstream.Get(constants.HeaderTraceParentKey))
+	assert.Equal(t, "rojo=00f067aa0ba902b7", downstream.Get(constants.HeaderTraceStateKey))
+}
+
+// TestTracingRootAndErrors 测试没有上游链路时新建链路，以及错误状态的记录
+func TestTracingRootAndErrors(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	engine := newTracingEngine(exporter)
+	engine.GET("/fail", func(ctx *Context) error {
+		return errors.New("数据库不可用")
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	spans := exporter.Spans()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	assert.Equal(t, SpanStatusError, spans[0].StatusCode)
+	assert.Equal(t, "数据库不可用", spans[0].StatusMessage)
+	assert.False(t, spans[1].ParentSpanID.IsValid())
+	assert.True(t, spans[1].SpanContext.TraceID.IsValid())
+}
+
+// TestTracingNotSampled 测试上游未采样时只传递链路不导出
+func TestTracingNotSampled(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	engine := newTracingEngine(exporter)
+	engine.GET("/", func(ctx *Context) error {
+		assert.False(t, ctx.Span().IsRecording())
+		ctx.Span().SetAttribute("ignored", true)
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(constants.HeaderTraceParentKey, strings.TrimSuffix(testTraceParent, "01")+"00")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	assert.Empty(t, exporter.Spans())
+	traceParent := recorder.Header().Get(constants.HeaderTraceParentKey)
+	assert.True(t, strings.HasPrefix(traceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	assert.True(t, strings.HasSuffix(traceParent, "-00"))
+}
+
+// TestOTLPFileExporter 测试以 OTLP/JSON 格式写入文件
+func TestOTLPFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	exporter, err := NewOTLPFileExporter(path)
+	assert.NoError(t, err)
+
+	engine := newTracingEngine(exporter)
+	engine.GET("/orders", func(ctx *Context) error {
+		return ctx.WriteString(http.StatusOK, "ok")
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.NoError(t, exporter.Shutdown(context.Background()))
+	assert.Equal(t, errorsx.ErrSpanExporterClosed, exporter.ExportSpans(context.Background(), []SpanData{{}}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2) // 每个请求一行
+
+	var request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string `json:"key"`
+					Value struct {
+						StringValue string `json:"stringValue"`
+					} `json:"value"`
+				} `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []map[string]any `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &request))
+	assert.Equal(t, "service.name", request.ResourceSpans[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "orders", request.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+	server := spans[1]
+	assert.Equal(t, "/orders", server["name"])
+	assert.Equal(t, float64(SpanKindServer), server["kind"])
+	assert.Len(t, server["traceId"], 32)
+	assert.Len(t, server["spanId"], 16)
+	assert.IsType(t, "", server["startTimeUnixNano"])
+	assert.Equal(t, server["spanId"], spans[0]["parentSpanId"])
+	assert.Contains(t, lines[0], `{"key":"http.response.status_code","value":{"intValue":"200"}}`)
+}
@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2024-11-15 08:59:07
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2024-11-17 13:55:55
+ * @LastEditTime: 2026-10-19 05:35:11
  * @FilePath: \gosh\tree.go
  * @Description: 路由树的实现，用于处理 URL 路径和参数。
  *
@@ -106,7 +106,7 @@ func (n *Node) incrementChildPrio(pos int) int {
 
 	// 调整位置（移动到前面）
 	newPos := pos
-	for newPos > 0 && cs[newPos-1].priority < prio {
+	for ; newPos > 0 && cs[newPos-1].priority < prio; newPos-- {
 		// 交换节点位置
 		cs[newPos-1], cs[newPos] = cs[newPos], cs[newPos-1]
 	}
@@ -159,10 +159,15 @@ walk:
 				continue walk
 			}
 
-			// 处理子节点
-			if n.handleChildNode(c, path, fullPath, handlers, &parentFullPathIndex) {
+			// 处理子节点，存在匹配的子节点时继续向下查找
+			next, done := n.handleChildNode(c, path, fullPath, handlers, &parentFullPathIndex)
+			if done {
 				return
 			}
+			if next != nil {
+				n = next
+				continue walk
+			}
 		}
 
 		// 注册处理函数
@@ -193,12 +198,14 @@ func (n *Node) splitNode(i int, path string, fullPath string) {
 }
 
 // handleChildNode 处理子节点的逻辑
-func (n *Node) handleChildNode(c byte, path string, fullPath string, handlers HandlersChain, parentFullPathIndex *int) bool {
+// 返回需要继续向下查找的子节点；done 为 true 表示已经插入新节点
+func (n *Node) handleChildNode(c byte, path string, fullPath string, handlers HandlersChain, parentFullPathIndex *int) (next *Node, done bool) {
 	// 查找具有相同路径字节的子节点
 	for i, maxIndices := 0, len(n.indices); i < maxIndices; i++ {
 		if c == n.indices[i] {
 			*parentFullPathIndex += len(n.path)
-			return false
+			i = n.incrementChildPrio(i)
+			return n.children[i], false
 		}
 	}
 
@@ -219,7 +226,7 @@ func (n *Node) handleChildNode(c byte, path string, fullPath string, handlers Ha
 		if len(path) >= len(n.path) && n.path == path[:len(n.path)] &&
 			n.nType != wildcardNode &&
 			(len(n.path) >= len(path) || path[len(n.path)] == constants.PathSeparator) {
-			return false
+			return n, false
 		}
 
 		// 检查通配符冲突
@@ -227,7 +234,7 @@ func (n *Node) handleChildNode(c byte, path string, fullPath string, handlers Ha
 	}
 
 	n.insertChild(path, fullPath, handlers) // 插入新的子节点
-	return true
+	return nil, true
 }
 
 // checkWildcardConflict 检查通配符冲突
@@ -0,0 +1,422 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 16:52:40
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:28:41
+ * @FilePath: \gosh\tus.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// StatusTusChecksumMismatch tus 协议定义的校验和不匹配状态码
+const StatusTusChecksumMismatch = 460
+
+// tusChecksumAlgorithms 支持的校验和算法
+var tusChecksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// TusConfig 断点续传配置
+type TusConfig struct {
+	Store      TusStore      // 存储实现，必填
+	MaxSize    int64         // 单个文件的最大字节数，为 0 表示不限制
+	Expiration time.Duration // 未完成的上传在最后一次写入后多久过期，为 0 表示不过期
+	// OnComplete 上传完成时调用，file 为完整的文件内容，返回错误时该次 PATCH 请求按错误处理
+	OnComplete func(ctx *Context, upload *TusUpload, file io.Reader) error
+}
+
+// tusHandler tus 1.0 协议处理程序
+type tusHandler struct {
+	config   TusConfig
+	basePath string              // 上传地址的绝对路径，用于生成 Location
+	mu       sync.Mutex          // 保护 writing
+	writing  map[string]struct{} // 正在写入的上传 ID，防止同一上传被并发写入
+}
+
+// Tus 在路由组上挂载 tus 1.0 断点续传处理程序
+// 支持 creation、termination、expiration 与 checksum 扩展：
+//
+//	OPTIONS relativePath       查询服务端能力
+//	POST    relativePath       创建上传
+//	HEAD    relativePath/:id   查询偏移量
+//	PATCH   relativePath/:id   追加内容
+//	DELETE  relativePath/:id   终止上传
+//
+// PATCH 请求不受 Config.MaxBodySize 限制，内容大小由 Upload-Length 约束
+func (group *RouterGroup) Tus(relativePath string, config TusConfig) error {
+	if config.Store == nil {
+		return errorsx.ErrTusStoreRequired
+	}
+	h := &tusHandler{
+		config:   config,
+		basePath: strings.TrimSuffix(group.calculateAbsolutePath(relativePath), "/"),
+		writing:  make(map[string]struct{}),
+	}
+	uploadPath := strings.TrimSuffix(relativePath, "/") + "/:id"
+
+	if err := group.OPTIONS(relativePath, h.options); err != nil {
+		return err
+	}
+	if err := group.POST(relativePath, h.create); err != nil {
+		return err
+	}
+	if err := group.HEAD(uploadPath, h.head); err != nil {
+		return err
+	}
+	if err := group.PATCH(uploadPath, h.patch); err != nil {
+		return err
+	}
+	return group.DELETE(uploadPath, h.terminate)
+}
+
+// extensions 返回支持的扩展列表
+func (h *tusHandler) extensions() string {
+	if h.config.Expiration > 0 {
+		return "creation,termination,expiration,checksum"
+	}
+	return "creation,termination,checksum"
+}
+
+// fail 写出纯文本的错误响应并中止请求
+func (h *tusHandler) fail(ctx *Context, code int, message string) error {
+	ctx.AbortWithStatusText(code, message)
+	return nil
+}
+
+// checkVersion 校验客户端的协议版本，不匹配时返回 412
+func (h *tusHandler) checkVersion(ctx *Context) bool {
+	ctx.SetHeader(constants.HeaderTusResumableKey, constants.TusVersion)
+	if ctx.Header(constants.HeaderTusResumableKey) == constants.TusVersion {
+		return true
+	}
+	ctx.SetHeader(constants.HeaderTusVersionKey, constants.TusVersion)
+	h.fail(ctx, http.StatusPreconditionFailed, "不支持的 tus 协议版本")
+	return false
+}
+
+// options 返回服务端支持的协议版本与扩展
+func (h *tusHandler) options(ctx *Context) error {
+	ctx.SetHeader(constants.HeaderTusResumableKey, constants.TusVersion)
+	ctx.SetHeader(constants.HeaderTusVersionKey, constants.TusVersion)
+	ctx.SetHeader(constants.HeaderTusExtensionKey, h.extensions())
+	ctx.SetHeader(constants.HeaderTusChecksumAlgorithmKey, "md5,sha1,sha256")
+	if h.config.MaxSize > 0 {
+		ctx.SetHeader(constants.HeaderTusMaxSizeKey, strconv.FormatInt(h.config.MaxSize, 10))
+	}
+	return ctx.WriteNoContent()
+}
+
+// create 创建上传，返回上传地址
+func (h *tusHandler) create(ctx *Context) error {
+	if !h.checkVersion(ctx) {
+		return nil
+	}
+	length, err := strconv.ParseInt(ctx.Header(constants.HeaderUploadLengthKey), 10, 64)
+	if err != nil || length < 0 {
+		return h.fail(ctx, http.StatusBadRequest, "Upload-Length 无效")
+	}
+	if h.config.MaxSize > 0 && length > h.config.MaxSize {
+		return h.fail(ctx, http.StatusRequestEntityTooLarge, "上传文件超出大小限制")
+	}
+	metadata, err := parseTusMetadata(ctx.Header(constants.HeaderUploadMetadataKey))
+	if err != nil {
+		return h.fail(ctx, http.StatusBadRequest, "Upload-Metadata 无效")
+	}
+
+	now := time.Now()
+	upload := &TusUpload{
+		ID:        newTusID(),
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+	}
+	if h.config.Expiration > 0 {
+		upload.ExpiresAt = now.Add(h.config.Expiration)
+	}
+	if err := h.config.Store.Create(upload); err != nil {
+		return err
+	}
+
+	// 空文件创建即完成
+	if length == 0 {
+		if err := h.complete(ctx, upload); err != nil {
+			return err
+		}
+	}
+
+	ctx.SetHeader(constants.HeaderLocationKey, ctx.Scheme()+"://"+ctx.Host()+h.basePath+"/"+upload.ID)
+	h.setExpires(ctx, upload)
+	ctx.Status = http.StatusCreated
+	ctx.ResponseWriter.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// head 返回上传的偏移量
+func (h *tusHandler) head(ctx *Context) error {
+	if !h.checkVersion(ctx) {
+		return nil
+	}
+	upload, ok, err := h.lookup(ctx)
+	if !ok {
+		return err
+	}
+	ctx.SetHeader(constants.HeaderCacheControlKey, "no-store")
+	ctx.SetHeader(constants.HeaderUploadOffsetKey, strconv.FormatInt(upload.Offset, 10))
+	ctx.SetHeader(constants.HeaderUploadLengthKey, strconv.FormatInt(upload.Length, 10))
+	if len(upload.Metadata) > 0 {
+		ctx.SetHeader(constants.HeaderUploadMetadataKey, encodeTusMetadata(upload.Metadata))
+	}
+	h.setExpires(ctx, upload)
+	return ctx.WriteNoContent()
+}
+
+// patch 从当前偏移量开始追加内容
+func (h *tusHandler) patch(ctx *Context) error {
+	if !h.checkVersion(ctx) {
+		return nil
+	}
+	if contentType, _, _ := strings.Cut(ctx.Header(constants.HeaderContentTypeKey), ";"); strings.TrimSpace(contentType) != constants.ContentTypeOffsetOctet {
+		return h.fail(ctx, http.StatusUnsupportedMediaType, "Content-Type 必须为 "+constants.ContentTypeOffsetOctet)
+	}
+	offset, err := strconv.ParseInt(ctx.Header(constants.HeaderUploadOffsetKey), 10, 64)
+	if err != nil || offset < 0 {
+		return h.fail(ctx, http.StatusBadRequest, "Upload-Offset 无效")
+	}
+
+	var checksum hash.Hash
+	var expected []byte
+	if header := ctx.Header(constants.HeaderUploadChecksumKey); header != "" {
+		algorithm, value, _ := strings.Cut(header, " ")
+		newHash, supported := tusChecksumAlgorithms[algorithm]
+		if !supported {
+			return h.fail(ctx, http.StatusBadRequest, "不支持的校验和算法")
+		}
+		if expected, err = base64.StdEncoding.DecodeString(value); err != nil {
+			return h.fail(ctx, http.StatusBadRequest, "Upload-Checksum 无效")
+		}
+		checksum = newHash()
+	}
+
+	upload, ok, err := h.lookup(ctx)
+	if !ok {
+		return err
+	}
+
+	// 同一上传同时只允许一个写入，加锁后重新读取最新的偏移量
+	if !h.acquire(upload.ID) {
+		return h.fail(ctx, http.StatusLocked, "上传正在被其他请求写入")
+	}
+	defer h.release(upload.ID)
+	if upload, err = h.config.Store.Get(upload.ID); err != nil {
+		return err
+	}
+	if offset != upload.Offset {
+		return h.fail(ctx, http.StatusConflict, "Upload-Offset 与服务端不一致")
+	}
+	// 已经完成的上传不再重复触发 OnComplete
+	wasComplete := upload.IsComplete()
+	remaining := upload.Length - upload.Offset
+	if ctx.Request.ContentLength > remaining {
+		return h.fail(ctx, http.StatusRequestEntityTooLarge, "上传内容超出 Upload-Length")
+	}
+
+	// 内容大小由 Upload-Length 约束，使用未被全局限制包装的请求体
+	body := ctx.Request.Body
+	if ctx.rawBody != nil {
+		body = ctx.rawBody
+	}
+	var src io.Reader = io.LimitReader(body, remaining)
+	if checksum != nil {
+		src = io.TeeReader(src, checksum)
+	}
+	written, writeErr := h.config.Store.WriteChunk(upload.ID, offset, src)
+
+	// 读满剩余长度后仍有内容，说明客户端发送的内容超出 Upload-Length
+	if writeErr == nil && written == remaining {
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			if err := h.config.Store.Truncate(upload.ID, offset); err != nil {
+				return err
+			}
+			return h.fail(ctx, http.StatusRequestEntityTooLarge, "上传内容超出 Upload-Length")
+		}
+	}
+	if checksum != nil {
+		// 带校验和的分片必须完整且正确，否则丢弃本次写入的全部内容
+		if writeErr != nil || subtle.ConstantTimeCompare(checksum.Sum(nil), expected) != 1 {
+			if err := h.config.Store.Truncate(upload.ID, offset); err != nil {
+				return err
+			}
+			if writeErr != nil {
+				return writeErr
+			}
+			return h.fail(ctx, StatusTusChecksumMismatch, "校验和不匹配")
+		}
+	}
+
+	// 连接中断时保留已经收到的内容，客户端可以通过 HEAD 查询偏移量后续传
+	upload.Offset += written
+	if h.config.Expiration > 0 {
+		upload.ExpiresAt = time.Now().Add(h.config.Expiration)
+	}
+	if err := h.config.Store.Save(upload); err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if upload.IsComplete() && !wasComplete {
+		if err := h.complete(ctx, upload); err != nil {
+			return err
+		}
+	}
+	ctx.SetHeader(constants.HeaderUploadOffsetKey, strconv.FormatInt(upload.Offset, 10))
+	h.setExpires(ctx, upload)
+	return ctx.WriteNoContent()
+}
+
+// terminate 终止上传并删除已收到的内容
+func (h *tusHandler) terminate(ctx *Context) error {
+	if !h.checkVersion(ctx) {
+		return nil
+	}
+	upload, ok, err := h.lookup(ctx)
+	if !ok {
+		return err
+	}
+	if err := h.config.Store.Delete(upload.ID); err != nil {
+		return err
+	}
+	return ctx.WriteNoContent()
+}
+
+// lookup 读取路径中的上传，不存在时返回 404，过期时删除并返回 410
+// ok 为 false 时响应已经写出或 err 不为空
+func (h *tusHandler) lookup(ctx *Context) (upload *TusUpload, ok bool, err error) {
+	upload, err = h.config.Store.Get(ctx.PathValue("id"))
+	if errors.Is(err, errorsx.ErrTusUploadNotFound) {
+		return nil, false, h.fail(ctx, http.StatusNotFound, "上传不存在")
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if upload.IsExpired(time.Now()) {
+		if err := h.config.Store.Delete(upload.ID); err != nil {
+			return nil, false, err
+		}
+		return nil, false, h.fail(ctx, http.StatusGone, "上传已过期")
+	}
+	return upload, true, nil
+}
+
+// acquire 标记上传正在写入，已经有请求在写入时返回 false
+func (h *tusHandler) acquire(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, busy := h.writing[id]; busy {
+		return false
+	}
+	h.writing[id] = struct{}{}
+	return true
+}
+
+// release 写入结束后移除标记，标记只在请求写入期间存在，不会因上传被放弃而残留
+func (h *tusHandler) release(id string) {
+	h.mu.Lock()
+	delete(h.writing, id)
+	h.mu.Unlock()
+}
+
+// complete 上传完成时把文件交给 OnComplete
+func (h *tusHandler) complete(ctx *Context, upload *TusUpload) error {
+	if h.config.OnComplete == nil {
+		return nil
+	}
+	file, err := h.config.Store.Open(upload.ID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return h.config.OnComplete(ctx, upload, file)
+}
+
+// setExpires 写出未完成上传的过期时间
+func (h *tusHandler) setExpires(ctx *Context, upload *TusUpload) {
+	if upload.ExpiresAt.IsZero() || upload.IsComplete() {
+		return
+	}
+	ctx.SetHeader(constants.HeaderUploadExpiresKey, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// newTusID 生成随机上传 ID
+func newTusID() string {
+	buf := make([]byte, tusIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// parseTusMetadata 解析 Upload-Metadata，格式为逗号分隔的 "key base64(value)"，值可以省略
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errorsx.ErrTusInvalidMetadata
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errorsx.ErrTusInvalidMetadata
+		}
+		metadata[key] = string(decoded)
+	}
+	return metadata, nil
+}
+
+// encodeTusMetadata 按键排序编码 Upload-Metadata
+func encodeTusMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if metadata[key] == "" {
+			pairs = append(pairs, key)
+			continue
+		}
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(metadata[key])))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,224 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 16:40:15
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 16:40:15
+ * @FilePath: \gosh\tus_store.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 常量定义
+const (
+	tusIDBytes       = 16      // 上传 ID 的随机字节数
+	tusDataFileExt   = ".bin"  // 上传内容文件扩展名
+	tusInfoFileExt   = ".info" // 上传信息文件扩展名
+	tusFilePerm      = 0o640   // 上传文件权限
+	tusDirectoryPerm = 0o750   // 上传目录权限
+)
+
+// TusUpload 一次断点续传上传的信息
+type TusUpload struct {
+	ID        string            `json:"id"`         // 上传 ID
+	Length    int64             `json:"length"`     // 文件总大小
+	Offset    int64             `json:"offset"`     // 已接收的字节数
+	Metadata  map[string]string `json:"metadata"`   // 客户端通过 Upload-Metadata 提供的元数据
+	CreatedAt time.Time         `json:"created_at"` // 创建时间
+	ExpiresAt time.Time         `json:"expires_at"` // 过期时间，零值表示不过期
+}
+
+// IsComplete 判断上传是否已完成
+func (u *TusUpload) IsComplete() bool {
+	return u.Offset >= u.Length
+}
+
+// IsExpired 判断上传是否已过期
+func (u *TusUpload) IsExpired(now time.Time) bool {
+	return !u.ExpiresAt.IsZero() && now.After(u.ExpiresAt)
+}
+
+// TusStore 断点续传存储接口
+// Get 在上传不存在时返回 errorsx.ErrTusUploadNotFound
+type TusStore interface {
+	Create(upload *TusUpload) error                                   // 创建上传并分配存储空间
+	Get(id string) (*TusUpload, error)                                // 获取上传信息
+	Save(upload *TusUpload) error                                     // 保存上传信息（偏移量、过期时间）
+	WriteChunk(id string, offset int64, src io.Reader) (int64, error) // 从 offset 开始写入内容，返回写入的字节数
+	Truncate(id string, size int64) error                             // 丢弃 size 之后的内容，用于校验失败时回滚
+	Open(id string) (io.ReadCloser, error)                            // 读取上传的内容
+	Delete(id string) error                                           // 删除上传
+}
+
+// TusDiskStore 本地磁盘存储，每个上传对应目录下的 <id>.bin 与 <id>.info 两个文件
+type TusDiskStore struct {
+	dir string
+}
+
+// NewTusDiskStore 创建本地磁盘存储，目录不存在时自动创建
+func NewTusDiskStore(dir string) (*TusDiskStore, error) {
+	if err := os.MkdirAll(dir, tusDirectoryPerm); err != nil {
+		return nil, err
+	}
+	return &TusDiskStore{dir: dir}, nil
+}
+
+// validTusID 校验上传 ID，防止路径穿越
+func validTusID(id string) bool {
+	if len(id) != tusIDBytes*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// Path 返回上传内容文件的路径，上传完成后可以直接移动该文件
+func (s *TusDiskStore) Path(id string) string {
+	return filepath.Join(s.dir, id+tusDataFileExt)
+}
+
+// infoPath 返回上传信息文件的路径
+func (s *TusDiskStore) infoPath(id string) string {
+	return filepath.Join(s.dir, id+tusInfoFileExt)
+}
+
+// Create 创建空的内容文件并写入上传信息
+func (s *TusDiskStore) Create(upload *TusUpload) error {
+	if !validTusID(upload.ID) {
+		return errorsx.ErrTusUploadNotFound
+	}
+	file, err := os.OpenFile(s.Path(upload.ID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, tusFilePerm)
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return s.Save(upload)
+}
+
+// Get 读取上传信息
+func (s *TusDiskStore) Get(id string) (*TusUpload, error) {
+	if !validTusID(id) {
+		return nil, errorsx.ErrTusUploadNotFound
+	}
+	content, err := os.ReadFile(s.infoPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errorsx.ErrTusUploadNotFound
+		}
+		return nil, err
+	}
+	var upload TusUpload
+	if err := json.Unmarshal(content, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Save 写入上传信息，先写入临时文件再重命名
+func (s *TusDiskStore) Save(upload *TusUpload) error {
+	content, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, upload.ID+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.infoPath(upload.ID))
+}
+
+// WriteChunk 从 offset 开始写入内容，连接中断时已写入的部分会保留
+func (s *TusDiskStore) WriteChunk(id string, offset int64, src io.Reader) (int64, error) {
+	if !validTusID(id) {
+		return 0, errorsx.ErrTusUploadNotFound
+	}
+	file, err := os.OpenFile(s.Path(id), os.O_WRONLY, tusFilePerm)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(file, src)
+}
+
+// Truncate 截断内容文件
+func (s *TusDiskStore) Truncate(id string, size int64) error {
+	if !validTusID(id) {
+		return errorsx.ErrTusUploadNotFound
+	}
+	return os.Truncate(s.Path(id), size)
+}
+
+// Open 打开内容文件
+func (s *TusDiskStore) Open(id string) (io.ReadCloser, error) {
+	if !validTusID(id) {
+		return nil, errorsx.ErrTusUploadNotFound
+	}
+	return os.Open(s.Path(id))
+}
+
+// Delete 删除内容文件与信息文件
+func (s *TusDiskStore) Delete(id string) error {
+	if !validTusID(id) {
+		return errorsx.ErrTusUploadNotFound
+	}
+	for _, path := range []string{s.Path(id), s.infoPath(id)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cleanup 删除所有已过期的上传，返回删除的数量，可以由定时任务调用
+func (s *TusDiskStore) Cleanup() (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	removed := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), tusInfoFileExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		upload, err := s.Get(id)
+		if err != nil || !upload.IsExpired(now) {
+			continue
+		}
+		if err := s.Delete(id); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,221 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 17:08:21
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:28:41
+ * @FilePath: \gosh\tus_test.go
+ * @Description: 测试 tus 断点续传功能
+ */
+package gosh
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// tusClient 测试用的 tus 客户端
+type tusClient struct {
+	t      *testing.T
+	engine *Engine
+	store  *TusDiskStore
+}
+
+// newTusClient 创建挂载在 /api/files 上的 tus 处理程序
+func newTusClient(t *testing.T, config TusConfig) *tusClient {
+	store, err := NewTusDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	config.Store = store
+
+	engine := NewEngine()
+	assert.NoError(t, engine.Group("/api").Tus("/files", config))
+	return &tusClient{t: t, engine: engine, store: store}
+}
+
+// do 发送带 Tus-Resumable 头的请求
+func (c *tusClient) do(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(constants.HeaderTusResumableKey, constants.TusVersion)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	c.engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// create 创建上传并返回上传路径
+func (c *tusClient) create(length string) string {
+	recorder := c.do(http.MethodPost, "/api/files", "", map[string]string{
+		constants.HeaderUploadLengthKey:   length,
+		constants.HeaderUploadMetadataKey: "filename " + base64.StdEncoding.EncodeToString([]byte("报告.pdf")) + ",is_confidential",
+	})
+	assert.Equal(c.t, http.StatusCreated, recorder.Code)
+	location := recorder.Header().Get(constants.HeaderLocationKey)
+	assert.True(c.t, strings.HasPrefix(location, "http://example.com/api/files/"))
+	return strings.TrimPrefix(location, "http://example.com")
+}
+
+// patch 从 offset 开始追加内容
+func (c *tusClient) patch(path, offset, body string, headers ...string) *httptest.ResponseRecorder {
+	h := map[string]string{
+		constants.HeaderContentTypeKey:  constants.ContentTypeOffsetOctet,
+		constants.HeaderUploadOffsetKey: offset,
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		h[headers[i]] = headers[i+1]
+	}
+	return c.do(http.MethodPatch, path, body, h)
+}
+
+// TestTusOptions 测试能力查询
+func TestTusOptions(t *testing.T) {
+	client := newTusClient(t, TusConfig{MaxSize: 1 << 20, Expiration: time.Hour})
+	req := httptest.NewRequest(http.MethodOptions, "/api/files", nil)
+	recorder := httptest.NewRecorder()
+	client.engine.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, constants.TusVersion, recorder.Header().Get(constants.HeaderTusVersionKey))
+	assert.Equal(t, "creation,termination,expiration,checksum", recorder.Header().Get(constants.HeaderTusExtensionKey))
+	assert.Equal(t, "1048576", recorder.Header().Get(constants.HeaderTusMaxSizeKey))
+	assert.Contains(t, recorder.Header().Get(constants.HeaderTusChecksumAlgorithmKey), "sha1")
+}
+
+// TestTusUpload 测试创建、查询偏移量、分片追加与完成回调
+func TestTusUpload(t *testing.T) {
+	var completed *TusUpload
+	var content []byte
+	calls := 0
+	client := newTusClient(t, TusConfig{
+		OnComplete: func(ctx *Context, upload *TusUpload, file io.Reader) error {
+			calls++
+			completed = upload
+			content, _ = io.ReadAll(file)
+			return nil
+		},
+	})
+	path := client.create("11")
+
+	recorder := client.do(http.MethodHead, path, "", nil)
+	assert.Equal(t, "0", recorder.Header().Get(constants.HeaderUploadOffsetKey))
+	assert.Equal(t, "11", recorder.Header().Get(constants.HeaderUploadLengthKey))
+	assert.Equal(t, "no-store", recorder.Header().Get(constants.HeaderCacheControlKey))
+	assert.Contains(t, recorder.Header().Get(constants.HeaderUploadMetadataKey), "is_confidential")
+
+	recorder = client.patch(path, "0", "hello")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "5", recorder.Header().Get(constants.HeaderUploadOffsetKey))
+	assert.Nil(t, completed)
+
+	// 偏移量不一致
+	recorder = client.patch(path, "3", " world")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = client.patch(path, "5", " world")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "11", recorder.Header().Get(constants.HeaderUploadOffsetKey))
+
+	if assert.NotNil(t, completed) {
+		assert.Equal(t, "报告.pdf", completed.Metadata["filename"])
+		assert.Equal(t, "hello world", string(content))
+		saved, err := os.ReadFile(client.store.Path(completed.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(saved))
+	}
+
+	// 已完成的上传再次 PATCH 不会重复触发完成回调
+	recorder = client.patch(path, "11", "")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "11", recorder.Header().Get(constants.HeaderUploadOffsetKey))
+	assert.Equal(t, 1, calls)
+}
+
+// TestTusChecksum 测试校验和不匹配时丢弃本次写入
+func TestTusChecksum(t *testing.T) {
+	client := newTusClient(t, TusConfig{})
+	path := client.create("5")
+
+	sum := sha1.Sum([]byte("hello"))
+	recorder := client.patch(path, "0", "hellO", constants.HeaderUploadChecksumKey, "sha1 "+base64.StdEncoding.EncodeToString(sum[:]))
+	assert.Equal(t, StatusTusChecksumMismatch, recorder.Code)
+	recorder = client.do(http.MethodHead, path, "", nil)
+	assert.Equal(t, "0", recorder.Header().Get(constants.HeaderUploadOffsetKey))
+
+	recorder = client.patch(path, "0", "hello", constants.HeaderUploadChecksumKey, "crc32 AAAA")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = client.patch(path, "0", "hello", constants.HeaderUploadChecksumKey, "sha1 "+base64.StdEncoding.EncodeToString(sum[:]))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "5", recorder.Header().Get(constants.HeaderUploadOffsetKey))
+}
+
+// TestTusRejections 测试协议版本、大小限制、内容类型与终止上传
+func TestTusRejections(t *testing.T) {
+	client := newTusClient(t, TusConfig{MaxSize: 10})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/files", nil)
+	req.Header.Set(constants.HeaderUploadLengthKey, "5")
+	recorder := httptest.NewRecorder()
+	client.engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	assert.Equal(t, constants.TusVersion, recorder.Header().Get(constants.HeaderTusVersionKey))
+
+	recorder = client.do(http.MethodPost, "/api/files", "", map[string]string{constants.HeaderUploadLengthKey: "11"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	path := client.create("5")
+	recorder = client.do(http.MethodPatch, path, "hello", map[string]string{constants.HeaderUploadOffsetKey: "0"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+
+	recorder = client.patch(path, "0", "hello world")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	recorder = client.do(http.MethodDelete, path, "", nil)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = client.do(http.MethodHead, path, "", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = client.do(http.MethodHead, "/api/files/not-a-hex-id", "", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestTusExpiration 测试过期上传返回 410 并被清理
+func TestTusExpiration(t *testing.T) {
+	client := newTusClient(t, TusConfig{Expiration: time.Hour})
+	path := client.create("5")
+	recorder := client.do(http.MethodHead, path, "", nil)
+	expires, err := http.ParseTime(recorder.Header().Get(constants.HeaderUploadExpiresKey))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, time.Minute)
+
+	// 手动把过期时间改到过去
+	id := path[strings.LastIndex(path, "/")+1:]
+	upload, err := client.store.Get(id)
+	assert.NoError(t, err)
+	upload.ExpiresAt = time.Now().Add(-time.Second)
+	assert.NoError(t, client.store.Save(upload))
+
+	recorder = client.patch(path, "0", "hello")
+	assert.Equal(t, http.StatusGone, recorder.Code)
+	recorder = client.do(http.MethodHead, path, "", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Cleanup 清理过期的上传
+	path = client.create("5")
+	id = path[strings.LastIndex(path, "/")+1:]
+	upload, _ = client.store.Get(id)
+	upload.ExpiresAt = time.Now().Add(-time.Second)
+	assert.NoError(t, client.store.Save(upload))
+	removed, err := client.store.Cleanup()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
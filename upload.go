@@ -0,0 +1,435 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 15:48:33
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 15:48:33
+ * @FilePath: \gosh\upload.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// 常量定义
+const (
+	defaultMaxFieldSize  = 1 << 20 // 默认普通表单字段大小限制 1 MB
+	uploadSniffLength    = 3072    // 内容嗅探读取的字节数，与 mimetype 默认值一致
+	maxFilenameLength    = 255     // 文件名最大字节数
+	defaultUploadName    = "file"  // 文件名清理后为空时使用的名称
+	maxUniqueNameRetries = 1000    // 生成不重复文件名的最大尝试次数
+)
+
+// UploadConfig 流式上传配置
+type UploadConfig struct {
+	MaxFileSize  int64            // 单个文件大小限制，为 0 表示不限制
+	MaxTotalSize int64            // 所有字段和文件的总大小限制，为 0 表示不限制
+	MaxFiles     int              // 文件数量限制，为 0 表示不限制
+	MaxFieldSize int64            // 普通字段大小限制(默认1MB)
+	AllowedTypes []string         // 允许的 MIME 类型(如image/png、image/*)，按文件内容嗅探判断，为空表示不限制
+	NewHash      func() hash.Hash // 写入文件时计算校验和使用的哈希算法(默认SHA-256)
+}
+
+// UploadedFile 已保存到磁盘的上传文件
+type UploadedFile struct {
+	FieldName   string // 表单字段名
+	FileName    string // 清理后的文件名
+	Path        string // 保存路径
+	ContentType string // 嗅探得到的 MIME 类型
+	Size        int64  // 文件大小
+	Checksum    string // 十六进制编码的校验和
+}
+
+// UploadReader 逐个读取 multipart 请求中的字段和文件，不会在内存或临时文件中缓冲整个请求
+type UploadReader struct {
+	config  UploadConfig
+	reader  *multipart.Reader
+	total   int64
+	files   int
+	current *UploadPart
+}
+
+// UploadPart multipart 请求中的一个字段或文件
+type UploadPart struct {
+	FieldName   string               // 表单字段名
+	FileName    string               // 清理后的文件名，普通字段为空
+	RawFileName string               // 客户端提供的原始文件名
+	Header      textproto.MIMEHeader // 该部分的请求头
+
+	upload      *UploadReader
+	part        *multipart.Part
+	size        int64
+	sniffed     bool
+	contentType string
+	reader      io.Reader
+}
+
+// UploadReader 返回流式上传读取器
+// 开启请求体缓存时会先缓存请求体，此时上传内容会被完整读取
+func (ctx *Context) UploadReader(config ...UploadConfig) (*UploadReader, error) {
+	cfg := UploadConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxFieldSize <= 0 {
+		cfg.MaxFieldSize = defaultMaxFieldSize
+	}
+	if cfg.NewHash == nil {
+		cfg.NewHash = sha256.New
+	}
+
+	if ctx.bodyCacheEnabled() {
+		if err := ctx.loadBodyCache(); err != nil {
+			return nil, err
+		}
+	}
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	return &UploadReader{config: cfg, reader: reader}, nil
+}
+
+// NextPart 返回下一个字段或文件，全部读取完毕时返回 io.EOF
+// 未读取完的上一部分会被自动跳过
+func (r *UploadReader) NextPart() (*UploadPart, error) {
+	if r.current != nil {
+		// 跳过的内容同样计入总大小，但不再检查文件类型
+		if _, err := io.Copy(io.Discard, readerFunc(r.current.readCounted)); err != nil {
+			return nil, err
+		}
+		r.current = nil
+	}
+
+	part, err := r.reader.NextPart()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &UploadPart{
+		FieldName:   part.FormName(),
+		RawFileName: part.FileName(),
+		Header:      part.Header,
+		upload:      r,
+		part:        part,
+	}
+	if p.RawFileName != "" {
+		r.files++
+		if r.config.MaxFiles > 0 && r.files > r.config.MaxFiles {
+			return nil, errorsx.ErrUploadTooManyFiles
+		}
+		p.FileName = SanitizeFilename(p.RawFileName)
+	}
+	r.current = p
+	return p, nil
+}
+
+// IsFile 判断该部分是否为文件
+func (p *UploadPart) IsFile() bool {
+	return p.RawFileName != ""
+}
+
+// Size 返回已读取的字节数
+func (p *UploadPart) Size() int64 {
+	return p.size
+}
+
+// readCounted 从 multipart 中读取并检查大小限制
+func (p *UploadPart) readCounted(b []byte) (int, error) {
+	n, err := p.part.Read(b)
+	p.size += int64(n)
+	p.upload.total += int64(n)
+
+	cfg := p.upload.config
+	switch {
+	case cfg.MaxTotalSize > 0 && p.upload.total > cfg.MaxTotalSize:
+		return n, errorsx.ErrUploadTooLarge
+	case p.IsFile() && cfg.MaxFileSize > 0 && p.size > cfg.MaxFileSize:
+		return n, errorsx.ErrUploadFileTooLarge
+	case !p.IsFile() && p.size > cfg.MaxFieldSize:
+		return n, errorsx.ErrUploadFieldTooLarge
+	}
+	return n, err
+}
+
+// sniff 读取文件开头的内容判断 MIME 类型并检查是否允许上传
+func (p *UploadPart) sniff() error {
+	if p.sniffed {
+		return nil
+	}
+	p.sniffed = true
+
+	head := make([]byte, uploadSniffLength)
+	n, err := io.ReadFull(readerFunc(p.readCounted), head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	head = head[:n]
+	p.reader = io.MultiReader(bytes.NewReader(head), readerFunc(p.readCounted))
+
+	if !p.IsFile() {
+		return nil
+	}
+	detected := mimetype.Detect(head)
+	p.contentType = detected.String()
+	if !mimeAllowed(detected, p.upload.config.AllowedTypes) {
+		return errorsx.ErrUploadTypeNotAllowed
+	}
+	return nil
+}
+
+// ContentType 返回嗅探得到的 MIME 类型，类型不被允许时返回错误
+func (p *UploadPart) ContentType() (string, error) {
+	if err := p.sniff(); err != nil {
+		return p.contentType, err
+	}
+	return p.contentType, nil
+}
+
+// Read 读取该部分的内容，文件类型不被允许或超出大小限制时返回错误
+func (p *UploadPart) Read(b []byte) (int, error) {
+	if err := p.sniff(); err != nil {
+		return 0, err
+	}
+	return p.reader.Read(b)
+}
+
+// Value 读取普通字段的值
+func (p *UploadPart) Value() (string, error) {
+	data, err := io.ReadAll(p)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SaveTo 将文件写入指定路径并设置文件权限为 perm，同时计算校验和
+// 内容先写入同目录下的临时文件，完整写入后再重命名，失败时不会留下不完整的文件
+func (p *UploadPart) SaveTo(path string, perm os.FileMode) (*UploadedFile, error) {
+	if err := p.sniff(); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := p.writeTo(tmp)
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	uploaded.Path = path
+	return uploaded, nil
+}
+
+// writeTo 写入文件并关闭，返回文件信息
+func (p *UploadPart) writeTo(file *os.File) (*UploadedFile, error) {
+	hasher := p.upload.config.NewHash()
+	size, err := io.Copy(io.MultiWriter(file, hasher), p)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &UploadedFile{
+		FieldName:   p.FieldName,
+		FileName:    p.FileName,
+		ContentType: p.contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hasher.Sum(nil)),
+	}, nil
+}
+
+// SaveUploads 将请求中的全部文件保存到 dir 目录，同时返回普通字段
+// 文件名重复时自动添加序号；任何一个文件失败时，已保存的文件都会被删除
+func (ctx *Context) SaveUploads(dir string, config ...UploadConfig) ([]*UploadedFile, url.Values, error) {
+	reader, err := ctx.UploadReader(config...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, nil, err
+	}
+
+	var files []*UploadedFile
+	fields := make(url.Values)
+	cleanup := func() {
+		for _, file := range files {
+			os.Remove(file.Path)
+		}
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return files, fields, nil
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+
+		if !part.IsFile() {
+			value, err := part.Value()
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			fields.Add(part.FieldName, value)
+			continue
+		}
+
+		uploaded, err := part.saveUnique(dir)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		files = append(files, uploaded)
+	}
+}
+
+// saveUnique 在目录中以不重复的文件名保存文件
+func (p *UploadPart) saveUnique(dir string) (*UploadedFile, error) {
+	if err := p.sniff(); err != nil {
+		return nil, err
+	}
+	file, err := createUniqueFile(dir, p.FileName)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := p.writeTo(file)
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+	uploaded.Path = file.Name()
+	uploaded.FileName = filepath.Base(file.Name())
+	return uploaded, nil
+}
+
+// createUniqueFile 创建不存在的文件，重名时依次尝试 name-1.ext、name-2.ext ...
+func createUniqueFile(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; i < maxUniqueNameRetries; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = base + "-" + strconv.Itoa(i) + ext
+		}
+		file, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+	}
+	return nil, os.ErrExist
+}
+
+// mimeAllowed 判断嗅探得到的类型是否在允许列表中，支持 image/* 形式的通配
+func mimeAllowed(detected *mimetype.MIME, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(detected.String())
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if detected.Is(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// windowsReservedNames Windows 保留的设备名
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename 清理客户端提供的文件名，使其可以安全地作为本地文件名使用
+// 去掉目录部分、控制字符和保留字符，避开 Windows 保留名，并限制长度（保留扩展名）
+func SanitizeFilename(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"|?*`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	name = strings.Trim(b.String(), " .")
+	if name == "" {
+		return defaultUploadName
+	}
+
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if windowsReservedNames[strings.ToUpper(base)] {
+		name = "_" + name
+	}
+
+	if len(name) > maxFilenameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > maxFilenameLength/2 {
+			ext = ""
+		}
+		base := name[:maxFilenameLength-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1] // 避免截断多字节字符
+		}
+		name = base + ext
+	}
+	return name
+}
+
+// readerFunc 将函数适配为 io.Reader
+type readerFunc func([]byte) (int, error)
+
+// Read 实现 io.Reader
+func (f readerFunc) Read(b []byte) (int, error) {
+	return f(b)
+}
@@ -0,0 +1,194 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 16:12:57
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:42:48
+ * @FilePath: \gosh\upload_test.go
+ * @Description: 测试流式上传功能
+ */
+package gosh
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// pngHeader 最小的 PNG 文件头，足以被识别为 image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+// uploadFile 测试用的上传文件
+type uploadFile struct {
+	field, name string
+	content     []byte
+}
+
+// newUploadRequest 构造 multipart 上传请求
+func newUploadRequest(fields map[string]string, files ...uploadFile) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	for _, file := range files {
+		part, _ := writer.CreateFormFile(file.field, file.name)
+		part.Write(file.content)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// TestSaveUploads 测试保存文件、校验和与重名处理
+func TestSaveUploads(t *testing.T) {
+	dir := t.TempDir()
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 4096)...)
+	sum := sha256.Sum256(png)
+
+	var files []*UploadedFile
+	engine := NewEngine()
+	engine.POST("/upload", func(ctx *Context) error {
+		saved, fields, err := ctx.SaveUploads(dir, UploadConfig{AllowedTypes: []string{"image/*"}})
+		if err != nil {
+			return err
+		}
+		files = saved
+		return ctx.WriteString(http.StatusOK, fields.Get("title"))
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, newUploadRequest(map[string]string{"title": "avatar"},
+		uploadFile{"avatar", `..\..\evil.png`, png},
+		uploadFile{"avatar", "evil.png", png},
+	))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "avatar", recorder.Body.String())
+
+	if assert.Len(t, files, 2) {
+		assert.Equal(t, "evil.png", files[0].FileName)
+		assert.Equal(t, "evil-1.png", files[1].FileName)
+		assert.Equal(t, "image/png", files[0].ContentType)
+		assert.Equal(t, int64(len(png)), files[0].Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), files[0].Checksum)
+		assert.Equal(t, dir, filepath.Dir(files[0].Path))
+
+		saved, err := os.ReadFile(files[1].Path)
+		assert.NoError(t, err)
+		assert.Equal(t, png, saved)
+	}
+}
+
+// TestUploadTypeNotAllowed 测试按内容嗅探拒绝伪装的文件
+func TestUploadTypeNotAllowed(t *testing.T) {
+	dir := t.TempDir()
+	engine := NewEngine()
+	engine.POST("/upload", func(ctx *Context) error {
+		_, _, err := ctx.SaveUploads(dir, UploadConfig{AllowedTypes: []string{"image/png", "image/jpeg"}})
+		assert.Equal(t, errorsx.ErrUploadTypeNotAllowed, err)
+		return nil
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), newUploadRequest(nil,
+		uploadFile{"ok", "ok.png", pngHeader},
+		uploadFile{"fake", "fake.png", []byte("<?php echo 'hi'; ?>")},
+	))
+	entries, _ := os.ReadDir(dir)
+	assert.Empty(t, entries) // 已保存的文件被清理
+}
+
+// TestUploadLimits 测试文件大小、总大小与文件数量限制
+func TestUploadLimits(t *testing.T) {
+	engine := NewEngine()
+	engine.POST("/upload", func(ctx *Context) error {
+		config := UploadConfig{MaxFileSize: 1024, MaxFiles: 2}
+		if ctx.QueryValue("total") != "" {
+			config = UploadConfig{MaxTotalSize: 1024}
+		}
+		_, _, err := ctx.SaveUploads(t.TempDir(), config)
+		return err
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, newUploadRequest(nil, uploadFile{"f", "big.bin", make([]byte, 2048)}))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "上传文件超出大小限制") // 保留具体的限制信息
+
+	req := newUploadRequest(nil, uploadFile{"a", "a.txt", []byte("a")}, uploadFile{"b", "b.txt", []byte("b")}, uploadFile{"c", "c.txt", []byte("c")})
+	recorder = httptest.NewRecorder()
+	var uploadErr error
+	engine.Config.ErrorHandler = func(ctx *Context) { uploadErr = ctx.Error }
+	engine.ServeHTTP(recorder, req)
+	assert.True(t, errors.Is(uploadErr, errorsx.ErrUploadTooManyFiles))
+	engine.Config.ErrorHandler = nil
+
+	req = newUploadRequest(nil, uploadFile{"a", "a.bin", make([]byte, 600)}, uploadFile{"b", "b.bin", make([]byte, 600)})
+	req.URL.RawQuery = "total=1"
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "上传内容超出总大小限制")
+}
+
+// TestUploadReaderStreaming 测试逐个读取字段与文件
+func TestUploadReaderStreaming(t *testing.T) {
+	engine := NewEngine()
+	engine.POST("/upload", func(ctx *Context) error {
+		reader, err := ctx.UploadReader()
+		if err != nil {
+			return err
+		}
+		var names []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if part.IsFile() {
+				contentType, err := part.ContentType()
+				assert.NoError(t, err)
+				names = append(names, part.FileName+":"+strings.SplitN(contentType, ";", 2)[0])
+				continue // 未读取的内容会被自动跳过
+			}
+			value, err := part.Value()
+			assert.NoError(t, err)
+			names = append(names, part.FieldName+"="+value)
+		}
+		return ctx.WriteString(http.StatusOK, strings.Join(names, ","))
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, newUploadRequest(map[string]string{"k": "v"}, uploadFile{"doc", "readme.txt", []byte("hello")}))
+	assert.Equal(t, "k=v,readme.txt:text/plain", recorder.Body.String())
+}
+
+// TestSanitizeFilename 测试文件名清理
+func TestSanitizeFilename(t *testing.T) {
+	assert.Equal(t, "passwd", SanitizeFilename("../../etc/passwd"))
+	assert.Equal(t, "evil.exe", SanitizeFilename(`C:\Windows\evil.exe`))
+	assert.Equal(t, "a_b_c.txt", SanitizeFilename("a<b>c.txt"))
+	assert.Equal(t, "file", SanitizeFilename(".."))
+	assert.Equal(t, "file", SanitizeFilename(""))
+	assert.Equal(t, "_con.txt", SanitizeFilename("con.txt"))
+	assert.Equal(t, "报告.pdf", SanitizeFilename("报告.pdf"))
+
+	long := SanitizeFilename(strings.Repeat("文", 200) + ".pdf")
+	assert.LessOrEqual(t, len(long), maxFilenameLength)
+	assert.True(t, strings.HasSuffix(long, ".pdf"))
+}
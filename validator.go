@@ -0,0 +1,179 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:08:03
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:30:41
+ * @FilePath: \gosh\validator.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/id"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/locales/tr"
+	"github.com/go-playground/locales/vi"
+	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	translator "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	estrans "github.com/go-playground/validator/v10/translations/es"
+	frtrans "github.com/go-playground/validator/v10/translations/fr"
+	idtrans "github.com/go-playground/validator/v10/translations/id"
+	ittrans "github.com/go-playground/validator/v10/translations/it"
+	jatrans "github.com/go-playground/validator/v10/translations/ja"
+	pttrans "github.com/go-playground/validator/v10/translations/pt"
+	ptbrtrans "github.com/go-playground/validator/v10/translations/pt_BR"
+	rutrans "github.com/go-playground/validator/v10/translations/ru"
+	trtrans "github.com/go-playground/validator/v10/translations/tr"
+	vitrans "github.com/go-playground/validator/v10/translations/vi"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+	zhtwtrans "github.com/go-playground/validator/v10/translations/zh_tw"
+)
+
+// validatorTranslation 校验器内置翻译
+type validatorTranslation struct {
+	locale   locales.Translator
+	register func(v *validator.Validate, trans translator.Translator) error
+}
+
+// validatorTranslations 引擎自动注册的校验器翻译，第一个为兜底语言
+var validatorTranslations = []validatorTranslation{
+	{en.New(), entrans.RegisterDefaultTranslations},
+	{zh.New(), zhtrans.RegisterDefaultTranslations},
+	{zh_Hant_TW.New(), zhtwtrans.RegisterDefaultTranslations},
+	{ja.New(), jatrans.RegisterDefaultTranslations},
+	{fr.New(), frtrans.RegisterDefaultTranslations},
+	{es.New(), estrans.RegisterDefaultTranslations},
+	{it.New(), ittrans.RegisterDefaultTranslations},
+	{id.New(), idtrans.RegisterDefaultTranslations},
+	{pt.New(), pttrans.RegisterDefaultTranslations},
+	{pt_BR.New(), ptbrtrans.RegisterDefaultTranslations},
+	{ru.New(), rutrans.RegisterDefaultTranslations},
+	{tr.New(), trtrans.RegisterDefaultTranslations},
+	{vi.New(), vitrans.RegisterDefaultTranslations},
+}
+
+// translatorAliases 繁体中文的常用写法，避免按上级语言退回到简体中文
+var translatorAliases = map[string]string{
+	"zh-tw":   "zh-hant-tw",
+	"zh-hk":   "zh-hant-tw",
+	"zh-mo":   "zh-hant-tw",
+	"zh-hant": "zh-hant-tw",
+}
+
+// Validator 返回引擎的校验器(默认Config.Validator)，首次调用时注册内置翻译
+// 自定义校验规则与翻译应在启动服务前通过该实例或 RegisterTranslation 注册
+func (engine *Engine) Validator() *validator.Validate {
+	engine.validatorOnce.Do(engine.initValidator)
+	return engine.validate
+}
+
+// initValidator 创建校验器并为每种语言注册内置翻译
+func (engine *Engine) initValidator() {
+	engine.validate = engine.Config.Validator
+	if engine.validate == nil {
+		engine.validate = validator.New()
+	}
+
+	fallback := validatorTranslations[0].locale
+	supported := make([]locales.Translator, 0, len(validatorTranslations))
+	for _, t := range validatorTranslations {
+		supported = append(supported, t.locale)
+	}
+	universal := translator.New(fallback, supported...)
+
+	engine.translators = make(map[string]translator.Translator, len(validatorTranslations))
+	for _, t := range validatorTranslations {
+		trans, _ := universal.GetTranslator(t.locale.Locale())
+		if err := t.register(engine.validate, trans); err != nil {
+			panic(fmt.Errorf("注册校验器翻译 %s 失败: %w", t.locale.Locale(), err))
+		}
+		engine.translators[strings.ToLower(normalizeLocale(t.locale.Locale()))] = trans
+	}
+}
+
+// findTranslator 按顺序为小写语言列表选择校验器翻译，支持上级语言匹配，都不匹配时返回 nil
+func (engine *Engine) findTranslator(tags ...string) translator.Translator {
+	engine.Validator()
+	for _, tag := range tags {
+		if alias, ok := translatorAliases[tag]; ok {
+			tag = alias
+		}
+		for parent := tag; parent != ""; parent = parentLocale(parent) {
+			if trans, ok := engine.translators[parent]; ok {
+				return trans
+			}
+		}
+	}
+	return nil
+}
+
+// RegisterTranslation 为自定义校验规则注册各语言的错误信息，messages 的键为语言，例如 "en"、"zh-CN"
+// 信息中 {0} 为字段名，{1} 为规则参数，例如 "{0}必须是{1}的倍数"
+func (engine *Engine) RegisterTranslation(tag string, messages map[string]string) error {
+	validate := engine.Validator()
+	for language, message := range messages {
+		trans := engine.findTranslator(strings.ToLower(normalizeLocale(language)))
+		if trans == nil {
+			return fmt.Errorf("校验器不支持语言 %s", language)
+		}
+		message := message
+		err := validate.RegisterTranslation(tag, trans,
+			func(ut translator.Translator) error {
+				return ut.Add(tag, message, true)
+			},
+			func(ut translator.Translator, fe validator.FieldError) string {
+				text, err := ut.T(tag, fe.Field(), fe.Param())
+				if err != nil {
+					return fe.Error()
+				}
+				return text
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Translator 返回当前请求使用的校验器翻译：配置了 Config.Trans 时总是使用它，
+// 否则依次根据 SetLanguage 设置的语言、查询参数与 Accept-Language 选择，都不支持时使用英文
+func (ctx *Context) Translator() translator.Translator {
+	if ctx.Engine == nil {
+		return nil
+	}
+	if ctx.Engine.Config.Trans != nil {
+		return ctx.Engine.Config.Trans
+	}
+	tags := ctx.languageTags()
+	if ctx.language != "" {
+		tags = append([]string{strings.ToLower(ctx.language)}, tags...)
+	}
+	if trans := ctx.Engine.findTranslator(tags...); trans != nil {
+		return trans
+	}
+	return ctx.Engine.findTranslator(validatorTranslations[0].locale.Locale())
+}
+
+// Validate 使用引擎的校验器校验结构体，校验失败时返回 validator.ValidationErrors，可以交给 ValidatorError 处理
+func (ctx *Context) Validate(obj any) error {
+	if ctx.Request != nil {
+		return ctx.Engine.Validator().StructCtx(ctx.Request.Context(), obj)
+	}
+	return ctx.Engine.Validator().Struct(obj)
+}
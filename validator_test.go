@@ -0,0 +1,110 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 06:09:10
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:43:20
+ * @FilePath: \gosh\validator_test.go
+ * @Description: 测试校验器翻译功能
+ */
+package gosh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/kamalyes/gosh/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+type signupForm struct {
+	Name  string `validate:"required"`
+	Seats int    `validate:"even"`
+}
+
+// TestValidatorTranslations 测试按 Accept-Language 与查询参数选择校验错误的语言
+func TestValidatorTranslations(t *testing.T) {
+	engine := NewEngine(Config{})
+	assert.NoError(t, engine.Validator().RegisterValidation("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	}))
+	assert.NoError(t, engine.RegisterTranslation("even", map[string]string{
+		"en":    "{0} must be an even number",
+		"zh-CN": "{0}必须是偶数",
+	}))
+	assert.Error(t, engine.RegisterTranslation("even", map[string]string{"ko": "{0}"}))
+
+	engine.POST("/signup", func(ctx *Context) error {
+		if err := ctx.Validate(&signupForm{Seats: 3}); err != nil {
+			ValidatorError(ctx, err)
+		}
+		return nil
+	})
+
+	errorsFor := func(target, language string) map[string]any {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		if language != "" {
+			req.Header.Set(constants.HeaderAcceptLanguageKey, language)
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	assert.Equal(t, map[string]any{
+		"Name":  "Name is a required field",
+		"Seats": "Seats must be an even number",
+	}, errorsFor("/signup", ""))
+	assert.Equal(t, map[string]any{
+		"Name":  "Name为必填字段",
+		"Seats": "Seats必须是偶数",
+	}, errorsFor("/signup", "zh-CN,zh;q=0.9,en;q=0.8"))
+	assert.Equal(t, "Nameは必須フィールドです", errorsFor("/signup", "ja-JP")["Name"])
+	assert.Equal(t, "Name為必填欄位", errorsFor("/signup?lang=zh-TW", "ja")["Name"])
+	assert.Equal(t, "Name is a required field", errorsFor("/signup", "ko-KR")["Name"])
+
+	// 未翻译的自定义规则退回校验器的原始信息
+	assert.Contains(t, errorsFor("/signup", "fr")["Seats"], "'even' tag")
+}
+
+// TestTranslatorSelection 测试按请求选择翻译、SetLanguage 与 Config.Trans 优先
+func TestTranslatorSelection(t *testing.T) {
+	newContext := func(engine *Engine, target, language string) *Context {
+		ctx := &Context{Engine: engine, Request: httptest.NewRequest(http.MethodGet, target, nil)}
+		if language != "" {
+			ctx.Request.Header.Set(constants.HeaderAcceptLanguageKey, language)
+		}
+		return ctx
+	}
+
+	engine := NewEngine(Config{LanguageQueryKey: "locale"})
+	assert.Equal(t, "en", newContext(engine, "/", "").Translator().Locale())
+	assert.Equal(t, "en", newContext(engine, "/", "ko").Translator().Locale())
+	assert.Equal(t, "pt_BR", newContext(engine, "/", "pt-BR").Translator().Locale())
+	assert.Equal(t, "zh_Hant_TW", newContext(engine, "/", "zh-HK").Translator().Locale())
+	assert.Equal(t, "fr", newContext(engine, "/?locale=fr-CA", "en").Translator().Locale())
+	assert.Equal(t, "en", newContext(engine, "/?lang=fr", "en").Translator().Locale())
+
+	ctx := newContext(engine, "/", "en")
+	ctx.SetLanguage("zh_CN")
+	assert.Equal(t, "zh", ctx.Translator().Locale())
+	assert.Equal(t, "zh-CN", ctx.Language())
+
+	// 关闭查询参数后只根据 Accept-Language 选择
+	engine = NewEngine(Config{LanguageQueryKey: LanguageQueryDisabled})
+	assert.Equal(t, "en", newContext(engine, "/?lang=fr&-=fr", "en").Translator().Locale())
+	assert.Equal(t, "en", newContext(engine, "/?lang=zh-CN", "en-US").Language())
+
+	// 配置了 Config.Trans 时不再按请求选择
+	custom := NewEngine(Config{}).findTranslator("ja")
+	engine = NewEngine(Config{Trans: custom})
+	assert.Equal(t, "ja", newContext(engine, "/", "en-US,en;q=0.9").Translator().Locale())
+	assert.Equal(t, "ja", newContext(engine, "/?lang=fr", "").Translator().Locale())
+}
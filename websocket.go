@@ -0,0 +1,964 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 09:12:36
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:31:58
+ * @FilePath: \gosh\websocket.go
+ * @Description:
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package gosh
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kamalyes/gosh/constants"
+	"github.com/kamalyes/gosh/errorsx"
+)
+
+// websocketGUID RFC 6455 规定的握手魔数
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// 常量定义
+const (
+	defaultWebSocketReadLimit        = 32 << 20 // 默认单条消息最大 32 MB
+	defaultWebSocketHandshakeTimeout = 10 * time.Second
+	defaultWebSocketWriteTimeout     = 10 * time.Second
+	defaultWebSocketBufferSize       = 4096
+	maxControlFramePayload           = 125
+)
+
+// WebSocketMessageType WebSocket 消息类型（与帧操作码一致）
+type WebSocketMessageType int
+
+// WebSocket 帧操作码
+const (
+	continuationFrame WebSocketMessageType = 0  // 延续帧
+	TextMessage       WebSocketMessageType = 1  // 文本消息
+	BinaryMessage     WebSocketMessageType = 2  // 二进制消息
+	CloseMessage      WebSocketMessageType = 8  // 关闭帧
+	PingMessage       WebSocketMessageType = 9  // Ping 帧
+	PongMessage       WebSocketMessageType = 10 // Pong 帧
+)
+
+// WebSocket 帧头标志位
+const (
+	finalBit = 1 << 7
+	rsv1Bit  = 1 << 6
+	rsv2Bit  = 1 << 5
+	rsv3Bit  = 1 << 4
+	maskBit  = 1 << 7
+)
+
+// WebSocket 关闭状态码（RFC 6455 7.4.1）
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+// isControl 判断是否为控制帧
+func (t WebSocketMessageType) isControl() bool {
+	return t >= CloseMessage
+}
+
+// CloseError 表示收到对端的关闭帧
+type CloseError struct {
+	Code   int    // 关闭状态码
+	Reason string // 关闭原因
+}
+
+// Error 实现 error 接口
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+// IsCloseError 判断错误是否为指定状态码的关闭错误，未指定状态码时只判断类型
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// WebSocketHandler WebSocket 连接处理器
+type WebSocketHandler func(ctx *Context, conn *WebSocketConn) error
+
+// WebSocketConfig WebSocket 参数配置
+type WebSocketConfig struct {
+	ReadLimit         int64                    // 单条消息最大字节数(默认32MB)，压缩消息按解压后大小计算
+	ReadBufferSize    int                      // 读缓冲区大小
+	WriteBufferSize   int                      // 写缓冲区大小
+	HandshakeTimeout  time.Duration            // 握手超时时间
+	WriteTimeout      time.Duration            // 单次写入超时时间
+	PingInterval      time.Duration            // 心跳间隔，为 0 表示不主动发送 Ping
+	PongTimeout       time.Duration            // 等待 Pong 的超时时间，默认与 PingInterval 相同
+	MaxFrameSize      int                      // 写入时单帧最大负载，超过则分片发送，为 0 表示不分片
+	Subprotocols      []string                 // 服务端支持的子协议，按优先级排列
+	EnableCompression bool                     // 是否协商 permessage-deflate 压缩
+	CompressionLevel  int                      // 压缩级别，为 0 时使用 flate.BestSpeed
+	CheckOrigin       func(*http.Request) bool // 校验 Origin，为 nil 时只允许同源请求
+}
+
+// withDefaults 填充默认值
+func (c WebSocketConfig) withDefaults() WebSocketConfig {
+	if c.ReadLimit <= 0 {
+		c.ReadLimit = defaultWebSocketReadLimit
+	}
+	if c.ReadBufferSize <= 0 {
+		c.ReadBufferSize = defaultWebSocketBufferSize
+	}
+	if c.WriteBufferSize <= 0 {
+		c.WriteBufferSize = defaultWebSocketBufferSize
+	}
+	if c.HandshakeTimeout <= 0 {
+		c.HandshakeTimeout = defaultWebSocketHandshakeTimeout
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = defaultWebSocketWriteTimeout
+	}
+	if c.PongTimeout <= 0 {
+		c.PongTimeout = c.PingInterval
+	}
+	if c.CompressionLevel == 0 {
+		c.CompressionLevel = flate.BestSpeed
+	}
+	return c
+}
+
+// WebSocket 注册 WebSocket 路由，握手请求会先经过路由组中的全部中间件
+func (group *RouterGroup) WebSocket(relativePath string, handler WebSocketHandler, config ...WebSocketConfig) error {
+	return group.GET(relativePath, func(ctx *Context) error {
+		conn, err := ctx.UpgradeWebSocket(config...)
+		if err != nil {
+			return nil // 握手失败的响应已经写出
+		}
+		defer conn.Close()
+
+		if err := handler(ctx, conn); err != nil && !IsCloseError(err) {
+			conn.WriteClose(CloseInternalServerErr, "")
+			return err
+		}
+		return nil
+	})
+}
+
+// IsWebSocket 判断当前请求是否为 WebSocket 握手请求
+func (ctx *Context) IsWebSocket() bool {
+	return headerContainsToken(ctx.Request.Header, constants.HeaderConnectionKey, strings.ToLower(constants.HeaderUpgradeKey)) &&
+		headerContainsToken(ctx.Request.Header, constants.HeaderUpgradeKey, constants.WebSocketUpgradeToken)
+}
+
+// UpgradeWebSocket 执行 RFC 6455 握手并劫持底层连接
+// 握手失败时会写出对应的错误响应并中止请求
+func (ctx *Context) UpgradeWebSocket(config ...WebSocketConfig) (*WebSocketConn, error) {
+	var cfg WebSocketConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg = cfg.withDefaults()
+
+	conn, status, err := ctx.upgradeWebSocket(cfg)
+	if err != nil {
+		var customErr *errorsx.CustomError
+		if status != 0 && errors.As(err, &customErr) {
+			handleError(ctx, ctx.Engine, customErr, status)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// upgradeWebSocket 校验握手请求并返回连接，失败时返回应答状态码
+func (ctx *Context) upgradeWebSocket(cfg WebSocketConfig) (*WebSocketConn, int, error) {
+	req := ctx.Request
+	if req.Method != http.MethodGet || !ctx.IsWebSocket() {
+		return nil, http.StatusBadRequest, errorsx.ErrWebSocketBadHandshake
+	}
+	if req.Header.Get(constants.HeaderSecWebSocketVersion) != constants.WebSocketVersion {
+		ctx.SetHeader(constants.HeaderSecWebSocketVersion, constants.WebSocketVersion)
+		return nil, http.StatusUpgradeRequired, errorsx.ErrWebSocketBadVersion
+	}
+	challengeKey := strings.TrimSpace(req.Header.Get(constants.HeaderSecWebSocketKey))
+	if decoded, err := base64.StdEncoding.DecodeString(challengeKey); err != nil || len(decoded) != 16 {
+		return nil, http.StatusBadRequest, errorsx.ErrWebSocketBadHandshake
+	}
+
+	checkOrigin := cfg.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(req) {
+		return nil, http.StatusForbidden, errorsx.ErrWebSocketOriginDenied
+	}
+
+	hijacker, ok := ctx.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, http.StatusInternalServerError, errorsx.ErrHijackNotSupported
+	}
+
+	subprotocol := selectSubprotocol(req.Header, cfg.Subprotocols)
+	compress := cfg.EnableCompression && negotiateDeflate(req.Header)
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		if errors.Is(err, errorsx.ErrHijackNotSupported) {
+			return nil, http.StatusInternalServerError, err
+		}
+		return nil, 0, err
+	}
+
+	// 手动写出 101 响应
+	var buf bytes.Buffer
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	buf.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	buf.WriteString(constants.HeaderSecWebSocketAccept + ": " + computeAcceptKey(challengeKey) + "\r\n")
+	if subprotocol != "" {
+		buf.WriteString(constants.HeaderSecWebSocketProtocol + ": " + subprotocol + "\r\n")
+	}
+	if compress {
+		buf.WriteString(constants.HeaderSecWebSocketExtensions + ": " + constants.WebSocketExtensionDeflate +
+			"; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	for key, values := range ctx.ResponseWriter.Header() {
+		if key == http.CanonicalHeaderKey(constants.HeaderSecWebSocketExtensions) || key == http.CanonicalHeaderKey(constants.HeaderSecWebSocketProtocol) {
+			continue
+		}
+		for _, value := range values {
+			buf.WriteString(key + ": " + value + "\r\n")
+		}
+	}
+	buf.WriteString("\r\n")
+
+	netConn.SetDeadline(time.Now().Add(cfg.HandshakeTimeout))
+	if _, err := netConn.Write(buf.Bytes()); err != nil {
+		netConn.Close()
+		return nil, 0, err
+	}
+	netConn.SetDeadline(time.Time{})
+
+	// 握手请求之后可能已有帧数据被读入缓冲区，此时必须继续使用原缓冲区
+	reader := brw.Reader
+	if reader.Buffered() == 0 {
+		reader = bufio.NewReaderSize(netConn, cfg.ReadBufferSize)
+	}
+	ctx.Status = http.StatusSwitchingProtocols
+	return newWebSocketConn(netConn, reader, true, subprotocol, compress, cfg), 0, nil
+}
+
+// computeAcceptKey 计算 Sec-WebSocket-Accept
+func computeAcceptKey(challengeKey string) string {
+	h := sha1.New()
+	h.Write([]byte(challengeKey))
+	h.Write([]byte(websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// checkSameOrigin 默认的同源校验，没有 Origin 头的请求（非浏览器客户端）直接放行
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get(constants.HeaderOriginKey)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContainsToken 判断以逗号分隔的请求头中是否包含指定 token（忽略大小写）
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectSubprotocol 选择第一个双方都支持的子协议
+func selectSubprotocol(header http.Header, supported []string) string {
+	for _, protocol := range supported {
+		if headerContainsToken(header, constants.HeaderSecWebSocketProtocol, protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// negotiateDeflate 判断客户端是否提供了 permessage-deflate 扩展
+func negotiateDeflate(header http.Header) bool {
+	for _, value := range header.Values(constants.HeaderSecWebSocketExtensions) {
+		for _, ext := range strings.Split(value, ",") {
+			name := strings.TrimSpace(strings.Split(ext, ";")[0])
+			if strings.EqualFold(name, constants.WebSocketExtensionDeflate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DialWebSocket 作为客户端连接 WebSocket 服务，支持 ws/wss 以及 http/https 地址
+// 主要用于服务间调用和基于 httptest 的测试
+func DialWebSocket(ctx context.Context, rawURL string, header http.Header, config ...WebSocketConfig) (*WebSocketConn, *http.Response, error) {
+	var cfg WebSocketConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg = cfg.withDefaults()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	useTLS := false
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+		useTLS = true
+	default:
+		return nil, nil, fmt.Errorf("websocket: 不支持的协议 %q", u.Scheme)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), map[bool]string{true: "443", false: "80"}[useTLS])
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, cfg.HandshakeTimeout)
+	defer cancel()
+
+	var netConn net.Conn
+	dialer := &net.Dialer{}
+	if useTLS {
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(dialCtx, "tcp", host)
+	} else {
+		netConn, err = dialer.DialContext(dialCtx, "tcp", host)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if deadline, ok := dialCtx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	challengeKey := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set(constants.HeaderUpgradeKey, constants.WebSocketUpgradeToken)
+	req.Header.Set(constants.HeaderConnectionKey, constants.HeaderUpgradeKey)
+	req.Header.Set(constants.HeaderSecWebSocketKey, challengeKey)
+	req.Header.Set(constants.HeaderSecWebSocketVersion, constants.WebSocketVersion)
+	if len(cfg.Subprotocols) > 0 {
+		req.Header.Set(constants.HeaderSecWebSocketProtocol, strings.Join(cfg.Subprotocols, ", "))
+	}
+	if cfg.EnableCompression {
+		req.Header.Set(constants.HeaderSecWebSocketExtensions, constants.WebSocketExtensionDeflate+"; client_no_context_takeover; server_no_context_takeover")
+	}
+
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReaderSize(netConn, cfg.ReadBufferSize)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(resp.Header, constants.HeaderUpgradeKey, constants.WebSocketUpgradeToken) ||
+		resp.Header.Get(constants.HeaderSecWebSocketAccept) != computeAcceptKey(challengeKey) {
+		netConn.Close()
+		return nil, resp, errorsx.ErrWebSocketBadHandshake
+	}
+	netConn.SetDeadline(time.Time{})
+
+	compress := cfg.EnableCompression && negotiateDeflate(resp.Header)
+	subprotocol := resp.Header.Get(constants.HeaderSecWebSocketProtocol)
+	return newWebSocketConn(netConn, reader, false, subprotocol, compress, cfg), resp, nil
+}
+
+// WebSocketConn 表示一个 WebSocket 连接
+// 同一时刻只允许一个 goroutine 读取，写入方法可以并发调用
+type WebSocketConn struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	isServer    bool
+	subprotocol string
+	compress    bool
+	config      WebSocketConfig
+
+	writeMu   sync.Mutex
+	writeBuf  []byte
+	closeSent bool
+
+	readLimit   int64
+	pingHandler func(appData string) error
+	pongHandler func(appData string) error
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newWebSocketConn 创建连接并按需启动心跳
+func newWebSocketConn(netConn net.Conn, reader *bufio.Reader, isServer bool, subprotocol string, compress bool, cfg WebSocketConfig) *WebSocketConn {
+	c := &WebSocketConn{
+		conn:        netConn,
+		reader:      reader,
+		isServer:    isServer,
+		subprotocol: subprotocol,
+		compress:    compress,
+		config:      cfg,
+		writeBuf:    make([]byte, 0, cfg.WriteBufferSize),
+		readLimit:   cfg.ReadLimit,
+		closed:      make(chan struct{}),
+	}
+	c.pingHandler = c.defaultPingHandler
+	c.pongHandler = c.defaultPongHandler
+
+	if cfg.PingInterval > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(cfg.PingInterval + cfg.PongTimeout))
+		go c.keepAlive()
+	}
+	return c
+}
+
+// Subprotocol 返回协商的子协议
+func (c *WebSocketConn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// CompressionEnabled 返回是否启用了 permessage-deflate
+func (c *WebSocketConn) CompressionEnabled() bool {
+	return c.compress
+}
+
+// RemoteAddr 返回对端地址
+func (c *WebSocketConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// LocalAddr 返回本地地址
+func (c *WebSocketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetReadLimit 设置单条消息的最大字节数，为 0 或负数时使用默认的 32MB
+func (c *WebSocketConn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// maxMessageSize 返回单条消息的最大字节数，总有上限，防止按对端声明的长度分配内存
+func (c *WebSocketConn) maxMessageSize() int64 {
+	if c.readLimit <= 0 {
+		return defaultWebSocketReadLimit
+	}
+	return c.readLimit
+}
+
+// SetReadDeadline 设置读取超时时间
+func (c *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetPingHandler 设置收到 Ping 帧时的回调，为 nil 时恢复默认行为（回复 Pong）
+func (c *WebSocketConn) SetPingHandler(h func(appData string) error) {
+	if h == nil {
+		h = c.defaultPingHandler
+	}
+	c.pingHandler = h
+}
+
+// SetPongHandler 设置收到 Pong 帧时的回调，为 nil 时恢复默认行为（延长读取超时）
+func (c *WebSocketConn) SetPongHandler(h func(appData string) error) {
+	if h == nil {
+		h = c.defaultPongHandler
+	}
+	c.pongHandler = h
+}
+
+// Done 返回连接关闭时会被关闭的通道
+func (c *WebSocketConn) Done() <-chan struct{} {
+	return c.closed
+}
+
+// defaultPingHandler 默认回复 Pong
+func (c *WebSocketConn) defaultPingHandler(appData string) error {
+	err := c.WriteControl(PongMessage, []byte(appData))
+	if errors.Is(err, errorsx.ErrWebSocketClosed) {
+		return nil
+	}
+	return err
+}
+
+// defaultPongHandler 默认在收到 Pong 后延长读取超时
+func (c *WebSocketConn) defaultPongHandler(string) error {
+	if c.config.PingInterval > 0 {
+		return c.conn.SetReadDeadline(time.Now().Add(c.config.PingInterval + c.config.PongTimeout))
+	}
+	return nil
+}
+
+// keepAlive 周期性发送 Ping
+func (c *WebSocketConn) keepAlive() {
+	ticker := time.NewTicker(c.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if err := c.WriteControl(PingMessage, nil); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// ReadMessage 读取一条完整的消息，自动合并分片、处理控制帧并解压
+// 收到关闭帧时返回 *CloseError
+func (c *WebSocketConn) ReadMessage() (WebSocketMessageType, []byte, error) {
+	var (
+		message     bytes.Buffer
+		messageType WebSocketMessageType
+		compressed  bool
+		fragmented  bool
+	)
+
+	for {
+		fin, rsv1, opcode, payload, err := c.readFrame(int64(message.Len()))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.pingHandler(string(payload)); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if err := c.pongHandler(string(payload)); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleCloseFrame(payload)
+		case TextMessage, BinaryMessage:
+			if fragmented {
+				return 0, nil, c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+			}
+			messageType = opcode
+			compressed = rsv1
+		case continuationFrame:
+			if !fragmented {
+				return 0, nil, c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+			}
+			if rsv1 {
+				return 0, nil, c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+			}
+		default:
+			return 0, nil, c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+		}
+
+		message.Write(payload)
+		if !fin {
+			fragmented = true
+			continue
+		}
+
+		data := message.Bytes()
+		if compressed {
+			if data, err = c.decompress(data); err != nil {
+				return 0, nil, err
+			}
+		}
+		if messageType == TextMessage && !utf8.Valid(data) {
+			return 0, nil, c.failConnection(CloseInvalidFramePayloadData, errorsx.ErrWebSocketInvalidUTF8)
+		}
+		return messageType, data, nil
+	}
+}
+
+// readFrame 读取并校验单个帧
+func (c *WebSocketConn) readFrame(received int64) (fin, rsv1 bool, opcode WebSocketMessageType, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&finalBit != 0
+	rsv1 = header[0]&rsv1Bit != 0
+	opcode = WebSocketMessageType(header[0] & 0x0f)
+	masked := header[1]&maskBit != 0
+	length := int64(header[1] & 0x7f)
+
+	if header[0]&(rsv2Bit|rsv3Bit) != 0 || (rsv1 && (!c.compress || opcode.isControl())) {
+		err = c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+		return
+	}
+	// 客户端发出的帧必须掩码，服务端发出的帧不能掩码
+	if masked != c.isServer {
+		err = c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+		return
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+		if length < 0 {
+			err = c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+			return
+		}
+	}
+
+	if opcode.isControl() {
+		if length > maxControlFramePayload || !fin {
+			err = c.failConnection(CloseProtocolError, errorsx.ErrWebSocketControlTooLong)
+			return
+		}
+	} else if length > c.maxMessageSize()-received {
+		err = c.failConnection(CloseMessageTooBig, errorsx.ErrWebSocketReadLimit)
+		return
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.reader, maskKey[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(maskKey, payload)
+	}
+	return
+}
+
+// handleCloseFrame 处理关闭帧并完成关闭握手
+func (c *WebSocketConn) handleCloseFrame(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	if len(payload) == 1 {
+		return c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+	}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !isValidCloseCode(closeErr.Code) || !utf8.ValidString(closeErr.Reason) {
+			return c.failConnection(CloseProtocolError, errorsx.ErrWebSocketProtocol)
+		}
+	}
+
+	// 对端发起关闭时回显状态码
+	echoCode := closeErr.Code
+	if echoCode == CloseNoStatusReceived {
+		echoCode = CloseNormalClosure
+	}
+	c.WriteClose(echoCode, "")
+	return closeErr
+}
+
+// isValidCloseCode 校验关闭状态码是否允许出现在关闭帧中
+func isValidCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// failConnection 以指定状态码关闭连接并返回原始错误
+func (c *WebSocketConn) failConnection(code int, err error) error {
+	c.WriteClose(code, "")
+	return err
+}
+
+// decompress 解压 permessage-deflate 消息并校验读取限制
+func (c *WebSocketConn) decompress(data []byte) ([]byte, error) {
+	// RFC 7692 7.2.2：补回被移除的尾部，再追加一个空的最终块
+	tail := []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+	reader := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(tail)))
+	defer reader.Close()
+
+	limit := c.maxMessageSize()
+	out, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, c.failConnection(CloseInvalidFramePayloadData, err)
+	}
+	if int64(len(out)) > limit {
+		return nil, c.failConnection(CloseMessageTooBig, errorsx.ErrWebSocketReadLimit)
+	}
+	return out, nil
+}
+
+// compressPayload 压缩消息并移除尾部的 0x00 0x00 0xff 0xff
+func (c *WebSocketConn) compressPayload(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, c.config.CompressionLevel)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0x00, 0x00, 0xff, 0xff}), nil
+}
+
+// WriteMessage 写入一条数据消息，超过 MaxFrameSize 时自动分片
+func (c *WebSocketConn) WriteMessage(messageType WebSocketMessageType, data []byte) error {
+	if messageType.isControl() {
+		return c.WriteControl(messageType, data)
+	}
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errorsx.ErrWebSocketProtocol
+	}
+
+	compressed := false
+	if c.compress {
+		payload, err := c.compressPayload(data)
+		if err != nil {
+			return err
+		}
+		data, compressed = payload, true
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errorsx.ErrWebSocketClosed
+	}
+
+	frameSize := c.config.MaxFrameSize
+	if frameSize <= 0 || frameSize >= len(data) {
+		return c.writeFrame(true, compressed, messageType, data)
+	}
+
+	opcode := messageType
+	for offset := 0; offset < len(data); offset += frameSize {
+		end := offset + frameSize
+		if end > len(data) {
+			end = len(data)
+		}
+		// 只有第一帧携带 RSV1 和消息类型
+		if err := c.writeFrame(end == len(data), compressed && offset == 0, opcode, data[offset:end]); err != nil {
+			return err
+		}
+		opcode = continuationFrame
+	}
+	return nil
+}
+
+// WriteText 写入文本消息
+func (c *WebSocketConn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+// WriteJSON 将数据序列化为 JSON 后以文本消息写入
+func (c *WebSocketConn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// ReadJSON 读取一条消息并解析为 JSON
+func (c *WebSocketConn) ReadJSON(v any) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteControl 写入控制帧（Ping/Pong/Close）
+func (c *WebSocketConn) WriteControl(messageType WebSocketMessageType, data []byte) error {
+	if !messageType.isControl() {
+		return errorsx.ErrWebSocketProtocol
+	}
+	if len(data) > maxControlFramePayload {
+		return errorsx.ErrWebSocketControlTooLong
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errorsx.ErrWebSocketClosed
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	return c.writeFrame(true, false, messageType, data)
+}
+
+// Ping 发送 Ping 帧
+func (c *WebSocketConn) Ping(data []byte) error {
+	return c.WriteControl(PingMessage, data)
+}
+
+// WriteClose 发送关闭帧，重复调用会被忽略
+func (c *WebSocketConn) WriteClose(code int, reason string) error {
+	var payload []byte
+	if code != CloseNoStatusReceived {
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlFramePayload {
+			payload = payload[:maxControlFramePayload]
+		}
+	}
+	err := c.WriteControl(CloseMessage, payload)
+	if errors.Is(err, errorsx.ErrWebSocketClosed) {
+		return nil
+	}
+	return err
+}
+
+// writeFrame 在持有写锁的前提下写入单个帧
+func (c *WebSocketConn) writeFrame(fin, rsv1 bool, opcode WebSocketMessageType, payload []byte) error {
+	buf := c.writeBuf[:0]
+
+	b0 := byte(opcode)
+	if fin {
+		b0 |= finalBit
+	}
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+	buf = append(buf, b0)
+
+	var b1 byte
+	if !c.isServer {
+		b1 |= maskBit
+	}
+	length := len(payload)
+	switch {
+	case length <= 125:
+		buf = append(buf, b1|byte(length))
+	case length <= 0xffff:
+		buf = append(buf, b1|126, byte(length>>8), byte(length))
+	default:
+		buf = append(buf, b1|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(length))
+	}
+
+	if c.isServer {
+		buf = append(buf, payload...)
+	} else {
+		var maskKey [4]byte
+		if _, err := rand.Read(maskKey[:]); err != nil {
+			return err
+		}
+		buf = append(buf, maskKey[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		maskBytes(maskKey, buf[start:])
+	}
+
+	if cap(buf) <= c.config.WriteBufferSize*4 {
+		c.writeBuf = buf // 复用写缓冲区，避免大消息长期占用内存
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// maskBytes 对负载执行掩码（掩码与去掩码操作相同）
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i&3]
+	}
+}
+
+// Close 发送正常关闭帧（如尚未发送）并关闭底层连接
+func (c *WebSocketConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.WriteClose(CloseNormalClosure, "")
+		close(c.closed)
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,186 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-10-19 09:40:12
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-10-19 06:31:58
+ * @FilePath: \gosh\websocket_test.go
+ * @Description: 测试 WebSocket 功能
+ */
+package gosh
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/gosh/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// newWebSocketServer 创建一个带回显路由的测试服务
+func newWebSocketServer(t *testing.T, config ...WebSocketConfig) (*httptest.Server, *Engine) {
+	engine := NewEngine()
+	engine.WebSocket("/ws", func(ctx *Context, conn *WebSocketConn) error {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return err
+			}
+		}
+	}, config...)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server, engine
+}
+
+// dialTestWebSocket 连接测试服务
+func dialTestWebSocket(t *testing.T, server *httptest.Server, path string, config ...WebSocketConfig) *WebSocketConn {
+	conn, _, err := DialWebSocket(context.Background(), server.URL+path, nil, config...)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// TestWebSocketEcho 测试文本和二进制消息回显
+func TestWebSocketEcho(t *testing.T) {
+	server, _ := newWebSocketServer(t)
+	conn := dialTestWebSocket(t, server, "/ws")
+
+	assert.NoError(t, conn.WriteText("hello"))
+	messageType, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, TextMessage, messageType)
+	assert.Equal(t, "hello", string(data))
+
+	assert.NoError(t, conn.WriteMessage(BinaryMessage, []byte{0x01, 0x02, 0x03}))
+	messageType, data, err = conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, BinaryMessage, messageType)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, data)
+}
+
+// TestWebSocketFragmentationAndCompression 测试分片与 permessage-deflate
+func TestWebSocketFragmentationAndCompression(t *testing.T) {
+	server, _ := newWebSocketServer(t, WebSocketConfig{EnableCompression: true, MaxFrameSize: 16})
+	conn := dialTestWebSocket(t, server, "/ws", WebSocketConfig{EnableCompression: true, MaxFrameSize: 7})
+	assert.True(t, conn.CompressionEnabled())
+
+	payload := strings.Repeat("gosh websocket ", 100)
+	assert.NoError(t, conn.WriteText(payload))
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, payload, string(data))
+}
+
+// TestWebSocketPingPong 测试 Ping/Pong 处理
+func TestWebSocketPingPong(t *testing.T) {
+	server, _ := newWebSocketServer(t)
+	conn := dialTestWebSocket(t, server, "/ws")
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(appData string) error {
+		pong <- appData
+		return nil
+	})
+	assert.NoError(t, conn.Ping([]byte("keepalive")))
+	assert.NoError(t, conn.WriteText("after ping"))
+
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "after ping", string(data))
+	assert.Equal(t, "keepalive", <-pong)
+}
+
+// TestWebSocketCloseHandshake 测试关闭握手
+func TestWebSocketCloseHandshake(t *testing.T) {
+	server, _ := newWebSocketServer(t)
+	conn := dialTestWebSocket(t, server, "/ws")
+
+	assert.NoError(t, conn.WriteClose(CloseGoingAway, "bye"))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseGoingAway))
+}
+
+// TestWebSocketReadLimit 测试读取限制
+func TestWebSocketReadLimit(t *testing.T) {
+	server, _ := newWebSocketServer(t, WebSocketConfig{ReadLimit: 8})
+	conn := dialTestWebSocket(t, server, "/ws")
+
+	assert.NoError(t, conn.WriteText("this message is too long"))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseMessageTooBig))
+}
+
+// TestWebSocketReadLimitAlwaysBounded 测试取消读取限制后仍拒绝超大帧
+func TestWebSocketReadLimitAlwaysBounded(t *testing.T) {
+	serverSide, clientSide := net.Pipe()
+	defer clientSide.Close()
+	conn := newWebSocketConn(serverSide, bufio.NewReader(serverSide), true, "", false, WebSocketConfig{})
+	conn.SetReadLimit(0)
+	defer conn.Close()
+
+	go func() {
+		// 带掩码的二进制帧，声明长度为 2^62 字节
+		clientSide.Write([]byte{0x82, 0xff, 0x40, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4})
+		io.Copy(io.Discard, clientSide)
+	}()
+
+	_, _, err := conn.ReadMessage()
+	assert.ErrorIs(t, err, errorsx.ErrWebSocketReadLimit)
+}
+
+// TestWebSocketMiddleware 测试握手请求经过中间件
+func TestWebSocketMiddleware(t *testing.T) {
+	engine := NewEngine()
+	group := engine.Group("/secure", func(ctx *Context) error {
+		if ctx.QueryValue("token") != "ok" {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+		}
+		return nil
+	})
+	group.WebSocket("/ws", func(ctx *Context, conn *WebSocketConn) error {
+		return conn.WriteText(ctx.QueryValue("token"))
+	})
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	_, resp, err := DialWebSocket(context.Background(), server.URL+"/secure/ws", nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	conn, _, err := DialWebSocket(context.Background(), server.URL+"/secure/ws?token=ok", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(data))
+}
+
+// TestWebSocketBadHandshake 测试非法握手请求
+func TestWebSocketBadHandshake(t *testing.T) {
+	_, engine := newWebSocketServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUpgradeRequired, recorder.Code)
+	assert.Equal(t, "13", recorder.Header().Get("Sec-WebSocket-Version"))
+}
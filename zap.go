@@ -2,7 +2,7 @@
  * @Author: kamalyes 501893067@qq.com
  * @Date: 2023-07-28 00:50:58
  * @LastEditors: kamalyes 501893067@qq.com
- * @LastEditTime: 2025-01-20 10:28:52
+ * @LastEditTime: 2026-10-19 06:04:33
  * @FilePath: \gosh\zap.go
  * @Description:
  *
@@ -166,11 +166,37 @@ func getEncoderCore(ctx Context, level zapcore.Level, kmZap kmZap.Zap) zapcore.C
 	return zapcore.NewCore(getEncoder(kmZap), writer, level) // 创建并返回核心
 }
 
+// nopLogger 返回丢弃所有日志的 Logger
+func nopLogger() *Logger {
+	return &Logger{
+		Logger:        zap.NewNop(),
+		requestIDKey:  constants.TraceIdKey,
+		timeKey:       constants.LogTimeKey,
+		errorKey:      constants.LogErrorKey,
+		requestKey:    constants.LogRequestKey,
+		stacktraceKey: constants.LogStacktraceKey,
+	}
+}
+
+// WithRequestID 返回绑定了请求 ID 的子 Logger，之后的每一行日志都带有请求 ID
+// 对 nil 调用时返回丢弃所有日志的 Logger
+func (l *Logger) WithRequestID(id string) *Logger {
+	if l == nil {
+		l = nopLogger()
+	}
+	if id == "" {
+		return l
+	}
+	child := *l
+	child.Logger = l.Logger.With(zap.String(l.requestIDKey, id))
+	return &child
+}
+
 // LogError 记录错误信息并返回 Logger 以支持链式调用
 func (l *Logger) LogError(message string, err interface{}, httpRequest []byte, includeStack bool) *Logger {
 	// 构建日志字段
+	// 请求 ID 由 WithRequestID 绑定，不在这里重复写入
 	fields := []zap.Field{
-		zap.String(l.requestIDKey, l.requestIDKey),    // 请求 ID
 		zap.Time(l.timeKey, time.Now()),               // 当前时间
 		zap.Any(l.errorKey, err),                      // 错误信息
 		zap.String(l.requestKey, string(httpRequest)), // 请求内容
@@ -195,6 +221,17 @@ func (l *Logger) LogRecovery(err interface{}, httpRequest []byte) *Logger {
 	return l.LogError("recovery from panic", err, httpRequest, true) // 调用 LogError 记录恢复信息
 }
 
+// LogRecoveryWithStack 记录从 panic 恢复的信息，使用调用方提供的堆栈，例如在其他 goroutine 中捕获的堆栈
+func (l *Logger) LogRecoveryWithStack(err interface{}, httpRequest []byte, stack []byte) *Logger {
+	l.Error("recovery from panic",
+		zap.Time(l.timeKey, time.Now()),
+		zap.Any(l.errorKey, err),
+		zap.String(l.requestKey, string(httpRequest)),
+		zap.ByteString(l.stacktraceKey, stack),
+	)
+	return l
+}
+
 // WriteSyncer 利用 lumberjack 库做日志分割
 func WriteSyncer(file string, kmZap kmZap.Zap) zapcore.WriteSyncer {
 	// 日志文件的最大大小（以 MB 为单位）